@@ -0,0 +1,163 @@
+package main
+
+import "strings"
+
+// Addresses of the filer, the paid preparer, the person keeping the books and
+// the 990-PF officers, US or foreign, one row per address. Country codes in
+// e-files follow the IRS country list (FIPS 10-4 based) and are converted to
+// ISO 3166-1 alpha-2.
+
+var addressesTable = tableSpec{
+	name: "addresses",
+	columns: []string{
+		"Role",
+		"Name",
+		"AddressLine1",
+		"AddressLine2",
+		"City",
+		"State",
+		"ZIPCode",
+		"ForeignPostalCode",
+		"Country",
+		"Foreign",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnHeader.Filer", values: map[string]string{"Role": "filer"}},
+		{path: "Return.ReturnHeader.PreparerFirmGrp", values: map[string]string{"Role": "preparer"}},
+		{path: form990Path + "BooksInCareOfDetail", values: map[string]string{"Role": "books_in_care_of"}},
+		{path: "Return.ReturnData.IRS990EZ.BooksInCareOfDetail", values: map[string]string{"Role": "books_in_care_of"}},
+		{path: "Return.ReturnData.IRS990PF.OfficerDirTrstKeyEmplInfoGrp.OfficerDirTrstKeyEmplGrp", values: map[string]string{"Role": "officer"}},
+	},
+	fields: addressFields(map[string]string{
+		"BusinessName.BusinessNameLine1Txt":        "Name",
+		"PreparerFirmName.BusinessNameLine1Txt":    "Name",
+		"PersonNm":                                 "Name",
+		"PreparerUSAddress.AddressLine1Txt":        "AddressLine1",
+		"PreparerUSAddress.AddressLine2Txt":        "AddressLine2",
+		"PreparerUSAddress.CityNm":                 "City",
+		"PreparerUSAddress.StateAbbreviationCd":    "State",
+		"PreparerUSAddress.ZIPCd":                  "ZIPCode",
+		"PreparerForeignAddress.AddressLine1Txt":   "AddressLine1",
+		"PreparerForeignAddress.AddressLine2Txt":   "AddressLine2",
+		"PreparerForeignAddress.CityNm":            "City",
+		"PreparerForeignAddress.ProvinceOrStateNm": "State",
+		"PreparerForeignAddress.ForeignPostalCd":   "ForeignPostalCode",
+		"PreparerForeignAddress.CountryCd":         "Country",
+	}),
+	derive: deriveAddressCountry,
+}
+
+// addressFields adds the USAddress and ForeignAddress children shared by the groups
+func addressFields(fields map[string]string) map[string]string {
+	for path, col := range map[string]string{
+		"USAddress.AddressLine1Txt":        "AddressLine1",
+		"USAddress.AddressLine2Txt":        "AddressLine2",
+		"USAddress.CityNm":                 "City",
+		"USAddress.StateAbbreviationCd":    "State",
+		"USAddress.ZIPCd":                  "ZIPCode",
+		"ForeignAddress.AddressLine1Txt":   "AddressLine1",
+		"ForeignAddress.AddressLine2Txt":   "AddressLine2",
+		"ForeignAddress.CityNm":            "City",
+		"ForeignAddress.ProvinceOrStateNm": "State",
+		"ForeignAddress.ForeignPostalCd":   "ForeignPostalCode",
+		"ForeignAddress.CountryCd":         "Country",
+	} {
+		fields[path] = col
+	}
+	return fields
+}
+
+// deriveAddressCountry marks US addresses, which always carry a ZIP code and
+// no country code
+func deriveAddressCountry(row map[string]string) {
+	switch {
+	case row["ZIPCode"] != "":
+		row["Country"] = "US"
+		row["Foreign"] = "false"
+	case row["AddressLine1"] != "":
+		row["Foreign"] = "true"
+	}
+}
+
+// summarizeAddresses fills the filer country and foreign flag and the
+// preparer address of the main record
+func (p *XMLToCSVProcessor) summarizeAddresses(tables *tableTracker, record []string) {
+	for _, row := range tables.rows[addressesTable.name] {
+		if row["AddressLine1"] == "" {
+			continue
+		}
+		switch row["Role"] {
+		case "filer":
+			record[p.fieldMap["Country"]] = row["Country"]
+			record[p.fieldMap["ForeignAddress"]] = row["Foreign"]
+		case "preparer":
+			record[p.fieldMap["PreparerAddress"]] = formatAddress(row)
+		}
+	}
+}
+
+// formatAddress renders an address row on one line
+func formatAddress(row map[string]string) string {
+	var parts []string
+	for _, col := range []string{"AddressLine1", "AddressLine2", "City"} {
+		if row[col] != "" {
+			parts = append(parts, row[col])
+		}
+	}
+	region := strings.TrimSpace(row["State"] + " " + row["ZIPCode"] + row["ForeignPostalCode"])
+	if region != "" {
+		parts = append(parts, region)
+	}
+	if row["Country"] != "" {
+		parts = append(parts, row["Country"])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isoCountryCodes maps IRS country codes to ISO 3166-1 alpha-2. Territories
+// without an ISO code (Akrotiri, Coral Sea Islands, Paracel Islands, ...) and
+// "Other Countries" are left out and reported as parse errors.
+var isoCountryCodes = map[string]string{
+	"AF": "AF", "AL": "AL", "AG": "DZ", "AQ": "AS", "AN": "AD", "AO": "AO",
+	"AV": "AI", "AY": "AQ", "AC": "AG", "AR": "AR", "AM": "AM", "AA": "AW",
+	"AS": "AU", "AU": "AT", "AJ": "AZ", "BF": "BS", "BA": "BH", "FQ": "UM",
+	"BG": "BD", "BB": "BB", "BO": "BY", "BE": "BE", "BH": "BZ", "BN": "BJ",
+	"BD": "BM", "BT": "BT", "BL": "BO", "BK": "BA", "BC": "BW", "BV": "BV",
+	"BR": "BR", "IO": "IO", "VI": "VG", "BX": "BN", "BU": "BG", "UV": "BF",
+	"BM": "MM", "BY": "BI", "CB": "KH", "CM": "CM", "CA": "CA", "CV": "CV",
+	"CJ": "KY", "CT": "CF", "CD": "TD", "CI": "CL", "CH": "CN", "KT": "CX",
+	"CK": "CC", "CO": "CO", "CN": "KM", "CF": "CG", "CG": "CD", "CW": "CK",
+	"CS": "CR", "IV": "CI", "HR": "HR", "CU": "CU", "UC": "CW", "CY": "CY",
+	"EZ": "CZ", "DA": "DK", "DJ": "DJ", "DO": "DM", "DR": "DO", "TT": "TL",
+	"EC": "EC", "EG": "EG", "ES": "SV", "EK": "GQ", "ER": "ER", "EN": "EE",
+	"ET": "ET", "FK": "FK", "FO": "FO", "FM": "FM", "FJ": "FJ", "FI": "FI",
+	"FR": "FR", "FP": "PF", "FS": "TF", "GB": "GA", "GA": "GM", "GG": "GE",
+	"GM": "DE", "GH": "GH", "GI": "GI", "GR": "GR", "GL": "GL", "GJ": "GD",
+	"GQ": "GU", "GT": "GT", "GK": "GG", "GV": "GN", "PU": "GW", "GY": "GY",
+	"HA": "HT", "HM": "HM", "VT": "VA", "HO": "HN", "HK": "HK", "HQ": "UM",
+	"HU": "HU", "IC": "IS", "IN": "IN", "ID": "ID", "IR": "IR", "IZ": "IQ",
+	"EI": "IE", "IS": "IL", "IT": "IT", "JM": "JM", "JN": "SJ", "JA": "JP",
+	"DQ": "UM", "JE": "JE", "JQ": "UM", "JO": "JO", "KZ": "KZ", "KE": "KE",
+	"KQ": "UM", "KR": "KI", "KN": "KP", "KS": "KR", "KV": "XK", "KU": "KW",
+	"KG": "KG", "LA": "LA", "LG": "LV", "LE": "LB", "LT": "LS", "LI": "LR",
+	"LY": "LY", "LS": "LI", "LH": "LT", "LU": "LU", "MC": "MO", "MK": "MK",
+	"MA": "MG", "MI": "MW", "MY": "MY", "MV": "MV", "ML": "ML", "MT": "MT",
+	"IM": "IM", "RM": "MH", "MR": "MR", "MP": "MU", "MX": "MX", "MQ": "UM",
+	"MD": "MD", "MN": "MC", "MG": "MN", "MJ": "ME", "MH": "MS", "MO": "MA",
+	"MZ": "MZ", "WA": "NA", "NR": "NR", "BQ": "UM", "NP": "NP", "NL": "NL",
+	"NC": "NC", "NZ": "NZ", "NU": "NI", "NG": "NE", "NI": "NG", "NE": "NU",
+	"NF": "NF", "CQ": "MP", "NO": "NO", "MU": "OM", "PK": "PK", "PS": "PW",
+	"LQ": "UM", "PM": "PA", "PP": "PG", "PA": "PY", "PE": "PE", "RP": "PH",
+	"PC": "PN", "PL": "PL", "PO": "PT", "RQ": "PR", "QA": "QA", "RO": "RO",
+	"RS": "RU", "RW": "RW", "TB": "BL", "RN": "MF", "WS": "WS", "SM": "SM",
+	"TP": "ST", "SA": "SA", "SG": "SN", "RI": "RS", "SE": "SC", "SL": "SL",
+	"SN": "SG", "NN": "SX", "LO": "SK", "SI": "SI", "BP": "SB", "SO": "SO",
+	"SF": "ZA", "SX": "GS", "OD": "SS", "SP": "ES", "CE": "LK", "SH": "SH",
+	"SC": "KN", "ST": "LC", "SB": "PM", "VC": "VC", "SU": "SD", "NS": "SR",
+	"SV": "SJ", "WZ": "SZ", "SW": "SE", "SZ": "CH", "SY": "SY", "TW": "TW",
+	"TI": "TJ", "TZ": "TZ", "TH": "TH", "TO": "TG", "TL": "TK", "TN": "TO",
+	"TD": "TT", "TS": "TN", "TU": "TR", "TX": "TM", "TK": "TC", "TV": "TV",
+	"UG": "UG", "UP": "UA", "AE": "AE", "UK": "GB", "UY": "UY", "UZ": "UZ",
+	"NH": "VU", "VE": "VE", "VM": "VN", "VQ": "VI", "WQ": "UM", "WF": "WF",
+	"WI": "EH", "YM": "YE", "ZA": "ZM", "ZI": "ZW",
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IRS Exempt Organizations Business Master File (EO BMF). sync downloads the
+// extracts into a monthly snapshot directory, data/eo_bmf/YYYY-MM, which is
+// never overwritten. csv joins the classification of each filing's EIN from
+// the snapshot in effect at the end of its tax period: the latest snapshot
+// taken in or before that month, or the earliest one when all were taken
+// later. BMFSnapshot names the snapshot used.
+
+const (
+	bmfDir     = "data/eo_bmf"
+	bmfBaseURL = "https://www.irs.gov/pub/irs-soi/"
+)
+
+// bmfFiles are the region extracts, which together cover every organization.
+// State extracts (eo_ca.csv, ...) placed in a snapshot directory are read too.
+var bmfFiles = []string{"eo1.csv", "eo2.csv", "eo3.csv", "eo4.csv"}
+
+// bmfColumns are the main CSV and panel columns joined from the BMF
+var bmfColumns = []string{
+	"BMFSnapshot",
+	"NTEECode",
+	"Subsection",
+	"FoundationCode",
+	"RulingDate",
+	"Affiliation",
+	"Deductibility",
+}
+
+// bmfFields maps BMF columns to the columns they fill
+var bmfFields = map[string]string{
+	"NTEE_CD":       "NTEECode",
+	"SUBSECTION":    "Subsection",
+	"FOUNDATION":    "FoundationCode",
+	"RULING":        "RulingDate",
+	"AFFILIATION":   "Affiliation",
+	"DEDUCTIBILITY": "Deductibility",
+}
+
+// bmfEntry is the classification of one EIN in one snapshot
+type bmfEntry struct {
+	snapshot string
+	values   map[string]string
+}
+
+// readBusinessMasterFile reads every snapshot under dir, keeping the EINs in
+// eins. Every EIN's history is in snapshot order.
+func readBusinessMasterFile(dir string, eins map[string]bool) (map[string][]bmfEntry, error) {
+	snapshots, err := snapshotDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][]bmfEntry)
+	for _, snapshotDir := range snapshots {
+		paths, err := filepath.Glob(filepath.Join(snapshotDir, "eo*.csv"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list BMF files: %w", err)
+		}
+		snapshot := filepath.Base(snapshotDir)
+		seen := make(map[string]bool)
+		for _, path := range paths {
+			if err := readBMFFile(path, snapshot, eins, seen, history); err != nil {
+				log.Printf("Error reading BMF file %s: %v", path, err)
+			}
+		}
+	}
+	return history, nil
+}
+
+// loadBusinessMasterFile returns the BMF reference set of the EINs in eins
+func loadBusinessMasterFile(dir string, eins map[string]bool) (referenceSet, error) {
+	set := referenceSet{name: "Business Master File"}
+
+	history, err := readBusinessMasterFile(dir, eins)
+	if err != nil {
+		return set, err
+	}
+
+	if len(history) == 0 {
+		return set, nil
+	}
+	set.found = len(history)
+	set.lookup = func(ein, taxPeriodEnd string) map[string]string {
+		entries := history[ein]
+		if len(entries) == 0 {
+			return nil
+		}
+		month := taxPeriodEnd
+		if len(month) > len("2006-01") {
+			month = month[:len("2006-01")]
+		}
+		entry := entries[0]
+		for _, e := range entries[1:] {
+			if e.snapshot <= month {
+				entry = e
+			}
+		}
+		return entry.values
+	}
+	return set, nil
+}
+
+// readBMFFile adds the rows of one extract to history. Region and state
+// extracts overlap, seen keeps the first row of an EIN within a snapshot.
+func readBMFFile(path, snapshot string, eins, seen map[string]bool, history map[string][]bmfEntry) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open BMF file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read BMF header: %w", err)
+	}
+	einCol := -1
+	fieldCols := make(map[int]string)
+	for i, col := range header {
+		col = strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))
+		if col == "EIN" {
+			einCol = i
+		} else if column, ok := bmfFields[col]; ok {
+			fieldCols[i] = column
+		}
+	}
+	if einCol < 0 {
+		return fmt.Errorf("BMF file has no EIN column")
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read BMF row: %w", err)
+		}
+		if einCol >= len(row) {
+			continue
+		}
+		ein := strings.TrimSpace(row[einCol])
+		if !eins[ein] || seen[ein] {
+			continue
+		}
+		seen[ein] = true
+
+		values := map[string]string{"BMFSnapshot": snapshot}
+		for i, column := range fieldCols {
+			if i < len(row) {
+				values[column] = strings.TrimSpace(row[i])
+			}
+		}
+		values["RulingDate"] = normalizeRulingDate(values["RulingDate"])
+		history[ein] = append(history[ein], bmfEntry{snapshot: snapshot, values: values})
+	}
+}
+
+// normalizeRulingDate writes the YYYYMM ruling date as YYYY-MM, empty when
+// the BMF has no ruling (000000)
+func normalizeRulingDate(value string) string {
+	if len(value) != len("200601") || strings.Trim(value, "0") == "" {
+		return ""
+	}
+	if t, err := time.Parse("200601", value); err == nil {
+		return t.Format("2006-01")
+	}
+	return value
+}
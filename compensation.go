@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Part VII Section A and Schedule J compensation tables. Both carry a
+// normalized NameKey so Schedule J rows join to Part VII rows by name and filing.
+
+const scheduleJPath = "Return.ReturnData.IRS990ScheduleJ."
+
+var partVIITable = tableSpec{
+	name: "part_vii",
+	columns: []string{
+		"PersonName",
+		"NameKey",
+		"Title",
+		"AverageHoursPerWeek",
+		"AverageHoursPerWeekRelated",
+		"TrusteeOrDirector",
+		"InstitutionalTrustee",
+		"Officer",
+		"KeyEmployee",
+		"HighestCompensated",
+		"Former",
+		"ReportableCompFromOrg",
+		"ReportableCompFromRelated",
+		"OtherCompensation",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnData.IRS990.Form990PartVIISectionAGrp"},
+	},
+	fields: map[string]string{
+		"PersonNm":                          "PersonName",
+		"BusinessName.BusinessNameLine1Txt": "PersonName",
+		"TitleTxt":                          "Title",
+		"AverageHoursPerWeekRt":             "AverageHoursPerWeek",
+		"AverageHoursPerWeekRltdOrgRt":      "AverageHoursPerWeekRelated",
+		"IndividualTrusteeOrDirectorInd":    "TrusteeOrDirector",
+		"InstitutionalTrusteeInd":           "InstitutionalTrustee",
+		"OfficerInd":                        "Officer",
+		"KeyEmployeeInd":                    "KeyEmployee",
+		"HighestCompensatedEmployeeInd":     "HighestCompensated",
+		"FormerOfcrDirectorTrusteeInd":      "Former",
+		"ReportableCompFromOrgAmt":          "ReportableCompFromOrg",
+		"ReportableCompFromRltdOrgAmt":      "ReportableCompFromRelated",
+		"OtherCompensationAmt":              "OtherCompensation",
+	},
+	derive: deriveNameKey,
+}
+
+var scheduleJTable = tableSpec{
+	name: "schedule_j",
+	columns: []string{
+		"PersonName",
+		"NameKey",
+		"Title",
+		"BaseCompensation",
+		"BaseCompensationRelated",
+		"Bonus",
+		"BonusRelated",
+		"OtherReportable",
+		"OtherReportableRelated",
+		"DeferredCompensation",
+		"DeferredCompensationRelated",
+		"NontaxableBenefits",
+		"NontaxableBenefitsRelated",
+		"TotalCompensation",
+		"TotalCompensationRelated",
+		"ReportedOnPrior990",
+		"ReportedOnPrior990Related",
+		// Part I policy indicators, repeated on every row of the filing
+		"FirstClassOrCharterTravel",
+		"TravelForCompanions",
+		"TaxIndemnificationGrossUp",
+		"DiscretionarySpendingAccount",
+		"HousingAllowanceOrResidence",
+		"PaymentsForUseOfResidence",
+		"HealthOrSocialClubDues",
+		"PersonalServices",
+		"CompensationCommittee",
+		"IndependentConsultant",
+		"CompensationSurvey",
+		"WrittenEmploymentContract",
+		"SeverancePayment",
+		"SupplementalNonqualRetirePlan",
+		"EquityBasedCompensation",
+	},
+	groups: []tableGroup{
+		{path: scheduleJPath + "RltdOrgOfficerTrstKeyEmplGrp"},
+	},
+	fields: map[string]string{
+		"PersonNm":                          "PersonName",
+		"BusinessName.BusinessNameLine1Txt": "PersonName",
+		"TitleTxt":                          "Title",
+		"BaseCompensationFilingOrgAmt":      "BaseCompensation",
+		"CompensationBasedOnRltdOrgsAmt":    "BaseCompensationRelated",
+		"BonusFilingOrganizationAmount":     "Bonus",
+		"BonusRelatedOrganizationsAmt":      "BonusRelated",
+		"OtherCompensationFilingOrgAmt":     "OtherReportable",
+		"OtherCompensationRltdOrgsAmt":      "OtherReportableRelated",
+		"DeferredCompensationFlngOrgAmt":    "DeferredCompensation",
+		"DeferredCompRltdOrgsAmt":           "DeferredCompensationRelated",
+		"NontaxableBenefitsFilingOrgAmt":    "NontaxableBenefits",
+		"NontaxableBenefitsRltdOrgsAmt":     "NontaxableBenefitsRelated",
+		"TotalCompensationFilingOrgAmt":     "TotalCompensation",
+		"TotalCompensationRltdOrgsAmt":      "TotalCompensationRelated",
+		"CompReportPrior990FilingOrgAmt":    "ReportedOnPrior990",
+		"CompReportPrior990RltdOrgsAmt":     "ReportedOnPrior990Related",
+	},
+	shared: map[string]string{
+		scheduleJPath + "FirstClassOrCharterTravelInd":     "FirstClassOrCharterTravel",
+		scheduleJPath + "TravelForCompanionsInd":           "TravelForCompanions",
+		scheduleJPath + "TaxIndemnificationAndGrossUpInd":  "TaxIndemnificationGrossUp",
+		scheduleJPath + "DiscretionarySpendingAcctInd":     "DiscretionarySpendingAccount",
+		scheduleJPath + "HousingAllowanceOrResidenceInd":   "HousingAllowanceOrResidence",
+		scheduleJPath + "PaymentsForUseOfResidenceInd":     "PaymentsForUseOfResidence",
+		scheduleJPath + "HealthOrSocialClubDuesInd":        "HealthOrSocialClubDues",
+		scheduleJPath + "PersonalServicesInd":              "PersonalServices",
+		scheduleJPath + "CompensationCommitteeInd":         "CompensationCommittee",
+		scheduleJPath + "IndependentConsultantInd":         "IndependentConsultant",
+		scheduleJPath + "CompensationSurveyInd":            "CompensationSurvey",
+		scheduleJPath + "WrittenEmploymentContractInd":     "WrittenEmploymentContract",
+		scheduleJPath + "SeverancePaymentInd":              "SeverancePayment",
+		scheduleJPath + "SupplementalNonqualRetirePlanInd": "SupplementalNonqualRetirePlan",
+		scheduleJPath + "EquityBasedCompArrngmInd":         "EquityBasedCompensation",
+	},
+	derive: deriveNameKey,
+}
+
+// deriveNameKey sets NameKey to an upper-cased person name with punctuation
+// dropped and whitespace collapsed, so "Doe, Jane  " and "DOE JANE" match
+func deriveNameKey(row map[string]string) {
+	row["NameKey"] = normalizeName(row["PersonName"])
+}
+
+func normalizeName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		case unicode.IsSpace(r):
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
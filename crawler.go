@@ -447,7 +447,7 @@ func CheckAndDownloadMissingZips() error {
 	return nil
 }
 
-// getAvailableZipFiles fetches the list of available zip files from the IRS website
+// getAvailableZipFiles fetches the list of available zip files and index files from the IRS website
 func getAvailableZipFiles() ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout*2)
 	defer cancel()
@@ -468,7 +468,7 @@ func getAvailableZipFiles() ([]string, error) {
 	walk = func(n *html.Node) {
 		if n.Type == html.ElementNode && n.Data == "a" {
 			for _, attr := range n.Attr {
-				if attr.Key == "href" && strings.Contains(attr.Val, ".zip") {
+				if attr.Key == "href" && (strings.Contains(attr.Val, ".zip") || isIndexFile(attr.Val)) {
 					links = append(links, attr.Val)
 				}
 			}
@@ -482,7 +482,7 @@ func getAvailableZipFiles() ([]string, error) {
 	return links, nil
 }
 
-// getDownloadedZipFiles gets the list of already downloaded zip files
+// getDownloadedZipFiles gets the list of already downloaded zip files and index files
 func getDownloadedZipFiles() ([]string, error) {
 	zipDir := "./data/990_zips"
 	
@@ -498,7 +498,7 @@ func getDownloadedZipFiles() ([]string, error) {
 	
 	var files []string
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".zip") {
+		if !entry.IsDir() && (strings.HasSuffix(strings.ToLower(entry.Name()), ".zip") || isIndexFile(entry.Name())) {
 			files = append(files, entry.Name())
 		}
 	}
@@ -517,7 +517,7 @@ func findMissingFiles(availableURLs, downloadedFiles []string) []string {
 	var missing []string
 	for _, url := range availableURLs {
 		filename := extractFilenameFromURL(url)
-		if !downloadedMap[filename] {
+		if !downloadedMap[filename] || isCurrentIndexFile(filename) {
 			missing = append(missing, url)
 		}
 	}
@@ -525,6 +525,57 @@ func findMissingFiles(availableURLs, downloadedFiles []string) []string {
 	return missing
 }
 
+// isIndexFile reports whether a URL or file name is a yearly index_YYYY.csv,
+// which lists every filing of the year with its submission date
+func isIndexFile(name string) bool {
+	base := strings.ToLower(extractFilenameFromURL(name))
+	return strings.HasPrefix(base, "index_") && strings.HasSuffix(base, ".csv")
+}
+
+// isCurrentIndexFile reports whether a file is the index of the current year,
+// which the IRS keeps appending to, so sync downloads it again every time
+func isCurrentIndexFile(name string) bool {
+	base := strings.ToLower(extractFilenameFromURL(name))
+	return base == fmt.Sprintf("index_%d.csv", time.Now().Year())
+}
+
+// downloadSnapshot downloads the files missing from the snapshot of the
+// current month, dir/YYYY-MM. Data sets the IRS replaces in place keep their
+// history that way.
+func downloadSnapshot(dir, baseURL string, files []string) error {
+	snapshot := filepath.Join(dir, time.Now().Format("2006-01"))
+	if err := os.MkdirAll(snapshot, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	fmt.Printf("Checking snapshot %s...\n", snapshot)
+	for _, name := range files {
+		path := filepath.Join(snapshot, name)
+		if err := downloadFile(baseURL+name, path); err != nil {
+			// A partial file would be taken as downloaded by the next sync
+			os.Remove(path)
+			fmt.Printf("Error downloading %s: %v\n", name, err)
+		}
+	}
+	return nil
+}
+
+// snapshotDirs lists the snapshot directories under dir, oldest first
+func snapshotDirs(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var dirs []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs, nil
+}
+
 // extractFilenameFromURL extracts the filename from a URL
 func extractFilenameFromURL(url string) string {
 	parts := strings.Split(url, "/")
@@ -544,11 +595,18 @@ func downloadSingleFile(url, filename string) error {
 
 	filePath := filepath.Join(zipDir, filename)
 
-	// Check if file already exists and has size > 0
-	if info, err := os.Stat(filePath); err == nil && info.Size() > 0 {
+	// Check if file already exists and has size > 0, the current year's index
+	// is replaced, but only once the new copy is complete
+	refresh := isCurrentIndexFile(filename)
+	if info, err := os.Stat(filePath); err == nil && info.Size() > 0 && !refresh {
 		log.Printf("File %s already exists, skipping", filename)
 		return nil
 	}
+	outPath := filePath
+	if refresh {
+		outPath = filePath + ".part"
+		defer os.Remove(outPath)
+	}
 
 	// Download the file with retry
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout*3)
@@ -561,7 +619,7 @@ func downloadSingleFile(url, filename string) error {
 	defer res.Body.Close()
 	
 	// Create the output file
-	out, err := os.Create(filePath)
+	out, err := os.Create(outPath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
@@ -577,6 +635,15 @@ func downloadSingleFile(url, filename string) error {
 	if written == 0 {
 		return fmt.Errorf("downloaded file is empty")
 	}
+
+	if refresh {
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := os.Rename(outPath, filePath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", filename, err)
+		}
+	}
 	
 	return nil
 }
@@ -10,22 +10,25 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"sync"
 	"sync/atomic"
 )
 
 // XMLToCSVProcessor handles converting XML files to CSV format
 type XMLToCSVProcessor struct {
-	outputFile *os.File
-	csvWriter  *csv.Writer
-	fieldMap   map[string]int
-	header     []string
-	mu         sync.Mutex
-	processed  atomic.Int64
+	outputFile  *os.File
+	csvWriter   *csv.Writer
+	fieldMap    map[string]int
+	header      []string
+	tables      []*tableWriter
+	provenance  *tableWriter // nil unless csvOptions.provenance
+	quality     *qualityReport
+	versions    []filingVersion
+	processed   atomic.Int64
+	parseErrors atomic.Int64
 }
 
 // NewXMLToCSVProcessor creates a new processor
-func NewXMLToCSVProcessor(outputPath string) (*XMLToCSVProcessor, error) {
+func NewXMLToCSVProcessor(outputPath string, options csvOptions) (*XMLToCSVProcessor, error) {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output file: %w", err)
@@ -176,6 +179,39 @@ func NewXMLToCSVProcessor(outputPath string) (*XMLToCSVProcessor, error) {
 		"ScheduleO",
 		"ScheduleR",
 		"AdditionalData",
+
+		// Added columns go after the original ones so column positions stay stable
+		"UnrestrictedNetAssetsBOY",
+		"UnrestrictedNetAssetsEOY",
+		"BalanceCheckBOY",
+		"BalanceCheckEOY",
+		"ProgramExpenseRatio",
+		"FundraisingEfficiency",
+		"AdministrativeOverhead",
+		"MonthsOfReserves",
+		"LiabilitiesToAssets",
+		"RevenueConcentration",
+		"SurplusMargin",
+		"RatioFlags",
+		"FailedRules",
+		"BMFSnapshot",
+		"NTEECode",
+		"Subsection",
+		"FoundationCode",
+		"RulingDate",
+		"Affiliation",
+		"Deductibility",
+		"Pub78Snapshot",
+		"Pub78Eligible",
+		"Pub78Deductibility",
+		"RevocationSnapshot",
+		"AutoRevoked",
+		"RevocationDate",
+		"RevocationPostingDate",
+		"ReinstatementDate",
+		"RevokedAtPeriodEnd",
+		"PayablesToOfficersBOY",
+		"PayablesToOfficersEOY",
 	}
 
 	// Create field map for quick lookup
@@ -191,62 +227,111 @@ func NewXMLToCSVProcessor(outputPath string) (*XMLToCSVProcessor, error) {
 	}
 	writer.Flush()
 
-	return &XMLToCSVProcessor{
+	processor := &XMLToCSVProcessor{
 		outputFile: file,
 		csvWriter:  writer,
 		fieldMap:   fieldMap,
 		header:     header,
-	}, nil
+		quality:    newQualityReport(header),
+	}
+
+	// Create one output file per side table
+	for _, spec := range tableSpecs {
+		table, err := newTableWriter(outputPath, spec)
+		if err != nil {
+			processor.Close()
+			return nil, err
+		}
+		processor.tables = append(processor.tables, table)
+	}
+
+	if options.provenance {
+		table, err := newTableWriter(outputPath, &provenanceTable)
+		if err != nil {
+			processor.Close()
+			return nil, err
+		}
+		processor.provenance = table
+	}
+
+	return processor, nil
 }
 
 // Close closes the processor and flushes data
 func (p *XMLToCSVProcessor) Close() error {
+	for _, table := range p.tables {
+		if err := table.Close(); err != nil {
+			log.Printf("Error closing table: %v", err)
+		}
+	}
+	if p.provenance != nil {
+		if err := p.provenance.Close(); err != nil {
+			log.Printf("Error closing table: %v", err)
+		}
+	}
 	p.csvWriter.Flush()
 	return p.outputFile.Close()
 }
 
 // ProcessDirectory processes all XML files in a directory
 func (p *XMLToCSVProcessor) ProcessDirectory(dirPath string) error {
+	return processXMLFiles(dirPath, p.processXMLFile)
+}
+
+// processXMLFiles runs process concurrently on every XML file in a directory.
+// process parses a file and returns the step that writes its output; the write
+// steps run one at a time in file name order, so the output does not depend on
+// which worker finishes first.
+func processXMLFiles(dirPath string, process func(path string) (func() error, error)) error {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return fmt.Errorf("failed to read directory %s: %w", dirPath, err)
 	}
 
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, runtime.NumCPU()*2) // Limit concurrent processing
+	// Results in file order. The buffer limits concurrent processing and the
+	// number of parsed files waiting for a slower one ahead of them.
+	pending := make(chan chan func() error, runtime.GOMAXPROCS(0)*2)
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+	go func() {
+		defer close(pending)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
 
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".xml") {
-			continue
+			if !strings.HasSuffix(strings.ToLower(entry.Name()), ".xml") {
+				continue
+			}
+
+			filePath := filepath.Join(dirPath, entry.Name())
+
+			result := make(chan func() error, 1)
+			pending <- result
+			go func(path string) {
+				write, err := process(path)
+				if err != nil {
+					log.Printf("Error processing %s: %v", path, err)
+				}
+				result <- write
+			}(filePath)
 		}
+	}()
 
-		filePath := filepath.Join(dirPath, entry.Name())
-		
-		wg.Add(1)
-		go func(path string) {
-			defer wg.Done()
-			semaphore <- struct{}{} // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
-
-			if err := p.processXMLFile(path); err != nil {
-				log.Printf("Error processing %s: %v", path, err)
+	for result := range pending {
+		if write := <-result; write != nil {
+			if err := write(); err != nil {
+				log.Printf("Error writing output: %v", err)
 			}
-		}(filePath)
+		}
 	}
-
-	wg.Wait()
 	return nil
 }
 
-// processXMLFile processes a single XML file
-func (p *XMLToCSVProcessor) processXMLFile(filePath string) error {
+// processXMLFile processes a single XML file and returns the step that writes it
+func (p *XMLToCSVProcessor) processXMLFile(filePath string) (func() error, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
@@ -261,29 +346,68 @@ func (p *XMLToCSVProcessor) processXMLFile(filePath string) error {
 
 	// Parse XML and extract data
 	decoder := xml.NewDecoder(file)
-	if err := p.extractXMLData(decoder, record); err != nil {
-		return fmt.Errorf("failed to parse XML: %w", err)
+	sources := make([]fieldSource, len(p.header))
+	tables := newTableTracker()
+	if err := p.extractXMLData(decoder, record, sources, tables); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+	p.summarizeRelated(tables, record)
+	p.summarizeFunctionalExpenses(tables, record)
+	p.summarizeBalanceSheet(tables, record)
+	p.summarizePrograms(tables, record)
+	p.summarizePublicSupport(tables)
+	p.summarizeDocuments(tables, record)
+	p.summarizeAddresses(tables, record)
+	p.summarizeRatios(record)
+	p.summarizeValidation(tables, record)
+
+	return func() error {
+		p.parseErrors.Add(int64(len(tables.rows[parseErrorsTable.name])))
+		p.quality.add(record, sources, p.fieldMap, tables)
+		p.versions = append(p.versions, p.filingVersionOf(record))
+
+		// Write record and its side table rows to CSV
+		if err := p.writeFiling(record, sources, tables); err != nil {
+			return err
+		}
+
+		// Increment counter
+		processed := p.processed.Add(1)
+		if processed%1000 == 0 {
+			log.Printf("Processed %d files", processed)
+		}
+
+		return nil
+	}, nil
+}
+
+// writeFiling writes the main record and the side table rows of one filing
+func (p *XMLToCSVProcessor) writeFiling(record []string, sources []fieldSource, tables *tableTracker) error {
+	keys := []string{
+		record[p.fieldMap["FileName"]],
+		record[p.fieldMap["EIN"]],
+		record[p.fieldMap["TaxYear"]],
 	}
 
-	// Write record to CSV
-	p.mu.Lock()
 	if err := p.csvWriter.Write(record); err != nil {
-		p.mu.Unlock()
 		return fmt.Errorf("failed to write record: %w", err)
 	}
-	p.mu.Unlock()
-
-	// Increment counter
-	processed := p.processed.Add(1)
-	if processed%1000 == 0 {
-		log.Printf("Processed %d files", processed)
+	for _, table := range p.tables {
+		if err := table.writeRows(tables.rows[table.spec.name], tables.shared[table.spec.name], keys); err != nil {
+			return err
+		}
+	}
+	if p.provenance != nil {
+		if err := p.provenance.writeRows(p.provenanceRows(record, sources), nil, keys); err != nil {
+			return err
+		}
 	}
-
 	return nil
 }
 
 // extractXMLData extracts relevant data from XML and populates the record
-func (p *XMLToCSVProcessor) extractXMLData(decoder *xml.Decoder, record []string) error {
+// and the side table rows
+func (p *XMLToCSVProcessor) extractXMLData(decoder *xml.Decoder, record []string, sources []fieldSource, tables *tableTracker) error {
 	var pathStack []string
 	var currentText string
 	var inElement bool
@@ -301,6 +425,16 @@ func (p *XMLToCSVProcessor) extractXMLData(decoder *xml.Decoder, record []string
 		switch t := token.(type) {
 		case xml.StartElement:
 			pathStack = append(pathStack, t.Name.Local)
+			tables.start(pathStack)
+			if len(t.Attr) > 0 {
+				elementPath := strings.Join(pathStack, ".")
+				for _, attr := range t.Attr {
+					if !isNamespaceAttr(attr) {
+						p.mapAttributeToRecord(elementPath+"@"+attr.Name.Local, attr.Value, record, sources)
+					}
+				}
+				tables.attrs(pathStack, t.Attr)
+			}
 			inElement = true
 			currentText = ""
 
@@ -314,9 +448,15 @@ func (p *XMLToCSVProcessor) extractXMLData(decoder *xml.Decoder, record []string
 				text := strings.TrimSpace(currentText)
 				if text != "" {
 					fullPath := strings.Join(pathStack, ".")
-					p.mapFieldToRecord(fullPath, text, record)
+					if value, err := normalizeValue(t.Name.Local, text); err != nil {
+						tables.parseError(fullPath, text, err)
+					} else {
+						p.mapFieldToRecord(fullPath, value, record, sources)
+						tables.leaf(pathStack, fullPath, value)
+					}
 				}
 			}
+			tables.end(pathStack)
 			if len(pathStack) > 0 {
 				pathStack = pathStack[:len(pathStack)-1]
 			}
@@ -327,54 +467,138 @@ func (p *XMLToCSVProcessor) extractXMLData(decoder *xml.Decoder, record []string
 	return nil
 }
 
-// mapFieldToRecord maps XML data to CSV record fields
-func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string) {
-	// Direct field mappings based on actual IRS 990 XML structure
-	fieldMappings := map[string]string{
-		"Return.ReturnHeader.Filer.EIN": "EIN",
-		"Return.ReturnHeader.Filer.BusinessName.BusinessNameLine1Txt": "OrganizationName",
-		"Return.ReturnHeader.TaxYr": "TaxYear",
-		"Return.ReturnHeader.ReturnTypeCd": "ReturnType",
-		"Return.ReturnHeader.Filer.USAddress.AddressLine1Txt": "AddressLine1",
-		"Return.ReturnHeader.Filer.USAddress.AddressLine2Txt": "AddressLine2",
-		"Return.ReturnHeader.Filer.USAddress.CityNm": "City",
-		"Return.ReturnHeader.Filer.USAddress.StateAbbreviationCd": "State",
-		"Return.ReturnHeader.Filer.USAddress.ZIPCd": "ZIPCode",
-		"Return.ReturnHeader.Filer.PhoneNum": "Phone",
-		"Return.ReturnHeader.ReturnTs": "FilingDate",
-		"Return.ReturnHeader.TaxPeriodBeginDt": "TaxPeriodBegin",
-		"Return.ReturnHeader.TaxPeriodEndDt": "TaxPeriodEnd",
-		"Return.ReturnHeader.PreparerPersonGrp.PreparerPersonNm": "PreparerName",
-		"Return.ReturnHeader.PreparerFirmGrp.PreparerFirmName.BusinessNameLine1Txt": "PreparerFirm",
-		"Return.ReturnHeader.BusinessOfficerGrp.PersonNm": "SignatureName",
-		"Return.ReturnHeader.BusinessOfficerGrp.PersonTitleTxt": "SignatureTitle",
-		"Return.ReturnHeader.BusinessOfficerGrp.SignatureDt": "SignatureDate",
-		
-		// Financial data mappings
-		"ReturnData.IRS990.CYTotalRevenueAmt": "TotalRevenue",
-		"ReturnData.IRS990.CYTotalExpensesAmt": "TotalExpenses",
-		"ReturnData.IRS990.TotalAssetsGrp.EOYAmt": "TotalAssets",
-		"ReturnData.IRS990.TotalLiabilitiesGrp.EOYAmt": "TotalLiabilities",
-		"ReturnData.IRS990.CYProgramServiceRevenueAmt": "ProgramServiceRevenue",
-		"ReturnData.IRS990.CYInvestmentIncomeAmt": "InvestmentIncome",
-		"ReturnData.IRS990.CYContributionsGrantsAmt": "Contributions",
-		"ReturnData.IRS990.CYGrantsAndSimilarPaidAmt": "Grants",
-		"ReturnData.IRS990.CYSalariesCompEmpBnftPaidAmt": "Salaries",
-		"ReturnData.IRS990.CYOtherExpensesAmt": "OtherExpenses",
-		"ReturnData.IRS990.TotalAssetsBOYAmt": "AssetsBOY",
-		"ReturnData.IRS990.TotalAssetsEOYAmt": "AssetsEOY",
-		"ReturnData.IRS990.TotalLiabilitiesBOYAmt": "LiabilitiesBOY",
-		"ReturnData.IRS990.TotalLiabilitiesEOYAmt": "LiabilitiesEOY",
-		"ReturnData.IRS990.NetAssetsOrFundBalancesBOYAmt": "NetAssetsBOY",
-		"ReturnData.IRS990.NetAssetsOrFundBalancesEOYAmt": "NetAssetsEOY",
-		"ReturnData.IRS990.MissionDesc": "Mission",
-		"ReturnData.IRS990.TotalProgramServiceExpensesAmt": "ExpensesForProgramServices",
+// fieldMappings maps exact element paths, and attribute paths written as
+// <element path>@<attribute>, to CSV record fields
+var fieldMappings = map[string]string{
+	"Return.ReturnHeader.Filer.EIN": "EIN",
+	"Return.ReturnHeader.Filer.BusinessName.BusinessNameLine1Txt": "OrganizationName",
+	"Return.ReturnHeader.TaxYr": "TaxYear",
+	"Return.ReturnHeader.ReturnTypeCd": "ReturnType",
+	"Return.ReturnHeader.Filer.USAddress.AddressLine1Txt": "AddressLine1",
+	"Return.ReturnHeader.Filer.USAddress.AddressLine2Txt": "AddressLine2",
+	"Return.ReturnHeader.Filer.USAddress.CityNm": "City",
+	"Return.ReturnHeader.Filer.USAddress.StateAbbreviationCd": "State",
+	"Return.ReturnHeader.Filer.USAddress.ZIPCd": "ZIPCode",
+	"Return.ReturnHeader.Filer.ForeignAddress.AddressLine1Txt": "AddressLine1",
+	"Return.ReturnHeader.Filer.ForeignAddress.AddressLine2Txt": "AddressLine2",
+	"Return.ReturnHeader.Filer.ForeignAddress.CityNm": "City",
+	"Return.ReturnHeader.Filer.ForeignAddress.ProvinceOrStateNm": "State",
+	"Return.ReturnHeader.Filer.ForeignAddress.ForeignPostalCd": "ZIPCode",
+	"Return.ReturnHeader.Filer.PhoneNum": "Phone",
+	"Return.ReturnHeader.ReturnTs": "FilingDate",
+	"Return.ReturnHeader.TaxPeriodBeginDt": "TaxPeriodBegin",
+	"Return.ReturnHeader.TaxPeriodEndDt": "TaxPeriodEnd",
+	"Return.ReturnHeader.PreparerPersonGrp.PreparerPersonNm": "PreparerName",
+	"Return.ReturnHeader.PreparerFirmGrp.PreparerFirmName.BusinessNameLine1Txt": "PreparerFirm",
+	"Return.ReturnHeader.BusinessOfficerGrp.PersonNm": "SignatureName",
+	"Return.ReturnHeader.BusinessOfficerGrp.PersonTitleTxt": "SignatureTitle",
+	"Return.ReturnHeader.BusinessOfficerGrp.SignatureDt": "SignatureDate",
+	"Return.ReturnHeader.SoftwareId": "SoftwareID",
+	"Return.ReturnHeader.SoftwareVersionNum": "SoftwareVersion",
+
+	// Amended return indicators, exact only since they decide which version of
+	// a filing is authoritative
+	"Return.ReturnData.IRS990.AmendedReturnInd": "AmendedReturn",
+	"Return.ReturnData.IRS990EZ.AmendedReturnInd": "AmendedReturn",
+	"Return.ReturnData.IRS990PF.AmendedReturnInd": "AmendedReturn",
+
+	// Document attributes
+	"Return@returnVersion": "FormVersion",
+	"Return.ReturnData.IRS990@softwareId": "SoftwareID",
+	"Return.ReturnData.IRS990@softwareVersionNum": "SoftwareVersion",
+	"Return.ReturnData.IRS990EZ@softwareId": "SoftwareID",
+	"Return.ReturnData.IRS990EZ@softwareVersionNum": "SoftwareVersion",
+	"Return.ReturnData.IRS990PF@softwareId": "SoftwareID",
+	"Return.ReturnData.IRS990PF@softwareVersionNum": "SoftwareVersion",
+	
+	// Financial data mappings
+	"Return.ReturnData.IRS990.CYTotalRevenueAmt": "TotalRevenue",
+	"Return.ReturnData.IRS990.CYTotalExpensesAmt": "TotalExpenses",
+	"Return.ReturnData.IRS990.TotalAssetsGrp.EOYAmt": "TotalAssets",
+	"Return.ReturnData.IRS990.TotalLiabilitiesGrp.EOYAmt": "TotalLiabilities",
+	"Return.ReturnData.IRS990.CYProgramServiceRevenueAmt": "ProgramServiceRevenue",
+	"Return.ReturnData.IRS990.CYInvestmentIncomeAmt": "InvestmentIncome",
+	"Return.ReturnData.IRS990.CYContributionsGrantsAmt": "Contributions",
+	"Return.ReturnData.IRS990.CYGrantsAndSimilarPaidAmt": "Grants",
+	"Return.ReturnData.IRS990.CYSalariesCompEmpBnftPaidAmt": "Salaries",
+	"Return.ReturnData.IRS990.CYOtherExpensesAmt": "OtherExpenses",
+	"Return.ReturnData.IRS990.TotalAssetsBOYAmt": "AssetsBOY",
+	"Return.ReturnData.IRS990.TotalAssetsEOYAmt": "AssetsEOY",
+	"Return.ReturnData.IRS990.TotalLiabilitiesBOYAmt": "LiabilitiesBOY",
+	"Return.ReturnData.IRS990.TotalLiabilitiesEOYAmt": "LiabilitiesEOY",
+	"Return.ReturnData.IRS990.NetAssetsOrFundBalancesBOYAmt": "NetAssetsBOY",
+	"Return.ReturnData.IRS990.NetAssetsOrFundBalancesEOYAmt": "NetAssetsEOY",
+	"Return.ReturnData.IRS990.MissionDesc": "Mission",
+
+	// Part VIII revenue and Part IX functional expense mappings
+	"Return.ReturnData.IRS990.GovernmentGrantsAmt": "RevenueFromGovernment",
+	"Return.ReturnData.IRS990.TotalContributionsAmt": "RevenueFromContributions",
+	"Return.ReturnData.IRS990.TotalProgramServiceRevenueAmt": "RevenueFromProgramServices",
+	"Return.ReturnData.IRS990.InvestmentIncomeGrp.TotalRevenueColumnAmt": "RevenueFromInvestment",
+	"Return.ReturnData.IRS990.OtherRevenueTotalAmt": "RevenueFromOther",
+	"Return.ReturnData.IRS990.OccupancyGrp.TotalAmt": "Occupancy",
+	"Return.ReturnData.IRS990.TotalFunctionalExpensesGrp.ProgramServicesAmt": "ExpensesForProgramServices",
+	"Return.ReturnData.IRS990.TotalFunctionalExpensesGrp.ManagementAndGeneralAmt": "ExpensesForManagement",
+	"Return.ReturnData.IRS990.TotalFunctionalExpensesGrp.FundraisingAmt": "ExpensesForFundraising",
+
+	// Part IV checklist indicators and Schedule F totals
+	"Return.ReturnData.IRS990.PoliticalCampaignActyInd": "PoliticalCampaignActivity",
+	"Return.ReturnData.IRS990.LobbyingActivitiesInd": "LobbyingActivity",
+	"Return.ReturnData.IRS990.ForeignActivitiesInd": "ForeignActivities",
+	"Return.ReturnData.IRS990ScheduleF.TotalSpentAmt": "ForeignExpenses",
+
+	// Schedule D Part VI reports land, buildings and equipment at end of year only
+	"Return.ReturnData.IRS990ScheduleD.LandGrp.BookValueAmt": "LandEOY",
+	"Return.ReturnData.IRS990ScheduleD.BuildingsGrp.BookValueAmt": "BuildingsEOY",
+	"Return.ReturnData.IRS990ScheduleD.EquipmentGrp.BookValueAmt": "EquipmentEOY",
+}
+
+// Match methods of main record values: an exact fieldMappings path, a name
+// pattern of mapFieldToRecord, or a summary computed from the side tables
+const (
+	matchExact     = "exact"
+	matchHeuristic = "heuristic"
+	matchDerived   = "derived"
+)
+
+// fieldSource is the element a main record value was read from
+type fieldSource struct {
+	path   string
+	method string
+	value  string
+}
+
+// setField writes value to column idx and remembers where it came from
+func setField(record []string, sources []fieldSource, idx int, value, path, method string) {
+	record[idx] = value
+	sources[idx] = fieldSource{path: path, method: method, value: value}
+}
+
+// sourceOf returns the source of column idx. Values that no mapping wrote, or
+// that a summary replaced afterwards, are derived.
+func sourceOf(record []string, sources []fieldSource, idx int) fieldSource {
+	if sources[idx].method == "" || sources[idx].value != record[idx] {
+		return fieldSource{method: matchDerived, value: record[idx]}
 	}
+	return sources[idx]
+}
 
+// mapAttributeToRecord maps an attribute through fieldMappings only, the name
+// patterns of mapFieldToRecord do not apply to attributes
+func (p *XMLToCSVProcessor) mapAttributeToRecord(path, value string, record []string, sources []fieldSource) {
+	if field, exists := fieldMappings[path]; exists {
+		if idx, ok := p.fieldMap[field]; ok {
+			setField(record, sources, idx, value, path, matchExact)
+		}
+	}
+}
+
+// mapFieldToRecord maps XML data to CSV record fields
+func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string, sources []fieldSource) {
 	// Check for direct mapping
 	if field, exists := fieldMappings[path]; exists {
 		if idx, ok := p.fieldMap[field]; ok {
-			record[idx] = value
+			setField(record, sources, idx, value, path, matchExact)
 		}
 		return
 	}
@@ -386,19 +610,19 @@ func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string
 	if strings.Contains(lowerPath, "revenue") || strings.Contains(lowerPath, "income") {
 		if strings.Contains(lowerPath, "total") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["TotalRevenue"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "program") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["ProgramServiceRevenue"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "investment") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["InvestmentIncome"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "contribution") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["Contributions"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		}
 	}
@@ -407,19 +631,19 @@ func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string
 	if strings.Contains(lowerPath, "expense") || strings.Contains(lowerPath, "cost") {
 		if strings.Contains(lowerPath, "total") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["TotalExpenses"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "program") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["ExpensesForProgramServices"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "management") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["ExpensesForManagement"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "fundraising") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["ExpensesForFundraising"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		}
 	}
@@ -429,29 +653,29 @@ func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string
 		if strings.Contains(lowerPath, "total") && strings.Contains(lowerPath, "amt") {
 			if strings.Contains(lowerPath, "boy") {
 				if idx, ok := p.fieldMap["AssetsBOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else if strings.Contains(lowerPath, "eoy") {
 				if idx, ok := p.fieldMap["AssetsEOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else {
 				if idx, ok := p.fieldMap["TotalAssets"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			}
 		} else if strings.Contains(lowerPath, "net") && strings.Contains(lowerPath, "amt") {
 			if strings.Contains(lowerPath, "boy") {
 				if idx, ok := p.fieldMap["NetAssetsBOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else if strings.Contains(lowerPath, "eoy") {
 				if idx, ok := p.fieldMap["NetAssetsEOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else {
 				if idx, ok := p.fieldMap["NetAssets"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			}
 		}
@@ -462,15 +686,15 @@ func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string
 		if strings.Contains(lowerPath, "total") && strings.Contains(lowerPath, "amt") {
 			if strings.Contains(lowerPath, "boy") {
 				if idx, ok := p.fieldMap["LiabilitiesBOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else if strings.Contains(lowerPath, "eoy") {
 				if idx, ok := p.fieldMap["LiabilitiesEOY"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			} else {
 				if idx, ok := p.fieldMap["TotalLiabilities"]; ok && record[idx] == "" {
-					record[idx] = value
+					setField(record, sources, idx, value, path, matchHeuristic)
 				}
 			}
 		}
@@ -480,57 +704,84 @@ func (p *XMLToCSVProcessor) mapFieldToRecord(path, value string, record []string
 	if strings.Contains(lowerPath, "compensation") || strings.Contains(lowerPath, "salary") {
 		if strings.Contains(lowerPath, "officer") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["OfficerCompensation"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "employee") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["EmployeeCompensation"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "total") && strings.Contains(lowerPath, "amt") {
 			if idx, ok := p.fieldMap["TotalCompensation"]; ok && record[idx] == "" {
-				record[idx] = value
+				setField(record, sources, idx, value, path, matchHeuristic)
 			}
 		}
 	}
 
 	// Boolean indicators
 	if value == "true" || value == "1" || value == "X" {
-		if strings.Contains(lowerPath, "amended") {
-			if idx, ok := p.fieldMap["AmendedReturn"]; ok {
-				record[idx] = "Yes"
-			}
-		} else if strings.Contains(lowerPath, "initial") {
+		if strings.Contains(lowerPath, "initial") {
 			if idx, ok := p.fieldMap["InitialReturn"]; ok {
-				record[idx] = "Yes"
+				setField(record, sources, idx, "true", path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "final") {
 			if idx, ok := p.fieldMap["FinalReturn"]; ok {
-				record[idx] = "Yes"
+				setField(record, sources, idx, "true", path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "terminated") {
 			if idx, ok := p.fieldMap["Terminated"]; ok {
-				record[idx] = "Yes"
+				setField(record, sources, idx, "true", path, matchHeuristic)
 			}
 		} else if strings.Contains(lowerPath, "electronic") {
 			if idx, ok := p.fieldMap["ElectronicFiling"]; ok {
-				record[idx] = "Yes"
+				setField(record, sources, idx, "true", path, matchHeuristic)
 			}
 		}
 	}
 }
 
-// ProcessAllDirectories processes all extracted directories
-func ProcessAllDirectories() error {
-	processor, err := NewXMLToCSVProcessor("irs_990_data.csv")
+// csvOptions selects the optional outputs of the csv command
+type csvOptions struct {
+	provenance bool // write the source of every main CSV value
+	latestOnly bool // drop superseded filings from the outputs
+}
+
+// ProcessAllDirectories processes all extracted directories, then resolves
+// amended and duplicate filings once every output file is complete and builds
+// the organization panel from the authoritative ones
+func ProcessAllDirectories(options csvOptions) error {
+	outputPath := "irs_990_data.csv"
+	baseDir := "data/990_zips"
+
+	versions, err := processDirectories(outputPath, baseDir, options)
+	if err != nil {
+		return err
+	}
+	superseded, err := resolveFilingVersions(outputPath, baseDir, versions, options)
+	if err != nil {
+		return err
+	}
+	references, err := loadReferenceSets(versions)
+	if err != nil {
+		return err
+	}
+	if err := joinReferenceSets(outputPath, references); err != nil {
+		return err
+	}
+	return writePanel(outputPath, versions, superseded, references)
+}
+
+// processDirectories writes the outputs of all extracted directories and
+// returns the version fields of every filing
+func processDirectories(outputPath, baseDir string, options csvOptions) ([]filingVersion, error) {
+	processor, err := NewXMLToCSVProcessor(outputPath, options)
 	if err != nil {
-		return fmt.Errorf("failed to create processor: %w", err)
+		return nil, fmt.Errorf("failed to create processor: %w", err)
 	}
 	defer processor.Close()
 
-	baseDir := "data/990_zips"
 	entries, err := os.ReadDir(baseDir)
 	if err != nil {
-		return fmt.Errorf("failed to read base directory: %w", err)
+		return nil, fmt.Errorf("failed to read base directory: %w", err)
 	}
 
 	// Process each directory
@@ -549,5 +800,11 @@ func ProcessAllDirectories() error {
 	}
 
 	log.Printf("Processing complete. Total files processed: %d", processor.processed.Load())
-	return nil
+	if count := processor.parseErrors.Load(); count > 0 {
+		log.Printf("Values that failed to parse: %d (see irs_990_data_parse_errors.csv)", count)
+	}
+	if err := processor.WriteQualityReport("irs_990_data_quality"); err != nil {
+		return nil, err
+	}
+	return processor.versions, nil
 } 
\ No newline at end of file
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Element path discovery. Streams the corpus and counts every leaf element and
+// attribute path per return type and returnVersion, then compares them with
+// the paths the CSV output maps: fieldMappings and the side table specs.
+
+const discoverOutput = "irs_990_paths.csv"
+
+var discoverHeader = []string{
+	"Status",
+	"ReturnType",
+	"ReturnVersion",
+	"ElementPath",
+	"Filings",
+	"Occurrences",
+	"MappedTo",
+}
+
+// pathKey identifies a path within one return type and version
+type pathKey struct {
+	returnType string
+	version    string
+	path       string
+}
+
+type pathCount struct {
+	filings     int
+	occurrences int
+}
+
+// PathDiscoverer accumulates path counts over the corpus
+type PathDiscoverer struct {
+	counts    map[pathKey]*pathCount
+	filings   map[[2]string]int // return type and version -> filings
+	processed atomic.Int64
+}
+
+// NewPathDiscoverer creates an empty discoverer
+func NewPathDiscoverer() *PathDiscoverer {
+	return &PathDiscoverer{
+		counts:  make(map[pathKey]*pathCount),
+		filings: make(map[[2]string]int),
+	}
+}
+
+// ProcessDirectory counts the paths of all XML files in a directory
+func (d *PathDiscoverer) ProcessDirectory(dirPath string) error {
+	return processXMLFiles(dirPath, d.discoverXMLFile)
+}
+
+// discoverXMLFile counts the leaf and attribute paths of a single XML file and
+// returns the step that adds them to the totals
+func (d *PathDiscoverer) discoverXMLFile(filePath string) (func() error, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	occurrences := make(map[string]int)
+	var pathStack []string
+	var hasChild []bool
+	var returnType, version string
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(hasChild) > 0 {
+				hasChild[len(hasChild)-1] = true
+			}
+			pathStack = append(pathStack, t.Name.Local)
+			hasChild = append(hasChild, false)
+
+			elementPath := strings.Join(pathStack, ".")
+			for _, attr := range t.Attr {
+				if isNamespaceAttr(attr) {
+					continue
+				}
+				occurrences[elementPath+"@"+attr.Name.Local]++
+				if elementPath == "Return" && attr.Name.Local == "returnVersion" {
+					version = attr.Value
+				}
+			}
+
+		case xml.CharData:
+			if len(pathStack) == 3 && pathStack[1] == "ReturnHeader" && pathStack[2] == "ReturnTypeCd" {
+				returnType += strings.TrimSpace(string(t))
+			}
+
+		case xml.EndElement:
+			if len(pathStack) == 0 {
+				continue
+			}
+			if !hasChild[len(hasChild)-1] {
+				occurrences[strings.Join(pathStack, ".")]++
+			}
+			pathStack = pathStack[:len(pathStack)-1]
+			hasChild = hasChild[:len(hasChild)-1]
+		}
+	}
+
+	return func() error {
+		d.filings[[2]string{returnType, version}]++
+		for path, n := range occurrences {
+			key := pathKey{returnType: returnType, version: version, path: path}
+			count, ok := d.counts[key]
+			if !ok {
+				count = &pathCount{}
+				d.counts[key] = count
+			}
+			count.filings++
+			count.occurrences += n
+		}
+
+		processed := d.processed.Add(1)
+		if processed%1000 == 0 {
+			log.Printf("Scanned %d files", processed)
+		}
+
+		return nil
+	}, nil
+}
+
+// mappedPaths lists every exact path the CSV output reads, with its targets:
+// main CSV columns, and table.column for side tables
+func mappedPaths() map[string][]string {
+	mapped := make(map[string][]string)
+	for path, field := range fieldMappings {
+		mapped[path] = append(mapped[path], field)
+	}
+	for _, spec := range tableSpecs {
+		for _, group := range spec.groups {
+			for rel, col := range spec.fields {
+				path := group.path
+				switch {
+				case rel == "":
+				case strings.HasPrefix(rel, "@"):
+					path += rel
+				default:
+					path += "." + rel
+				}
+				mapped[path] = append(mapped[path], spec.name+"."+col)
+			}
+		}
+		for path, col := range spec.shared {
+			mapped[path] = append(mapped[path], spec.name+"."+col)
+		}
+	}
+	for path := range mapped {
+		sort.Strings(mapped[path])
+	}
+	return mapped
+}
+
+// capturedBy names the side table columns that collect path without a spec
+// field: narrative text and document attributes
+func capturedBy(path string) []string {
+	element, attr, isAttr := strings.Cut(path, "@")
+	parts := strings.Split(element, ".")
+	if len(parts) < 3 || parts[1] != "ReturnData" {
+		return nil
+	}
+
+	if isAttr {
+		switch {
+		case attr == "referenceDocumentId" || attr == "referenceDocumentName":
+			return []string{documentReferencesTable.name + "." + strings.ToUpper(attr[:1]) + attr[1:]}
+		case len(parts) == 3 && (attr == "documentId" || attr == "softwareId" || attr == "softwareVersionNum"):
+			return []string{documentsTable.name + "." + strings.ToUpper(attr[:1]) + attr[1:]}
+		}
+		return nil
+	}
+
+	name := parts[len(parts)-1]
+	switch {
+	case name == "FormAndLineReferenceDesc":
+		return []string{narrativeTable.name + ".LineReference"}
+	case narrativeExcluded[name]:
+		return nil
+	case strings.HasSuffix(name, "Txt") || strings.HasSuffix(name, "Desc"):
+		return []string{narrativeTable.name + ".Text"}
+	}
+	return nil
+}
+
+// WriteReport writes the path frequency report and prints a summary per
+// return type and version
+func (d *PathDiscoverer) WriteReport(outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(discoverHeader); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	keys := make([]pathKey, 0, len(d.counts))
+	for key := range d.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.returnType != b.returnType {
+			return a.returnType < b.returnType
+		}
+		if a.version != b.version {
+			return a.version < b.version
+		}
+		return a.path < b.path
+	})
+
+	mapped := mappedPaths()
+	seen := make(map[string]bool)
+	type summary struct{ mapped, unmapped int }
+	summaries := make(map[[2]string]*summary)
+
+	for _, key := range keys {
+		count := d.counts[key]
+		status := "unmapped"
+		targets, ok := mapped[key.path]
+		if !ok {
+			targets = capturedBy(key.path)
+			ok = len(targets) > 0
+		}
+		if ok {
+			status = "mapped"
+			seen[key.path] = true
+		}
+
+		group := [2]string{key.returnType, key.version}
+		if summaries[group] == nil {
+			summaries[group] = &summary{}
+		}
+		if ok {
+			summaries[group].mapped++
+		} else {
+			summaries[group].unmapped++
+		}
+
+		row := []string{
+			status,
+			key.returnType,
+			key.version,
+			key.path,
+			strconv.Itoa(count.filings),
+			strconv.Itoa(count.occurrences),
+			strings.Join(targets, " "),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	// Mapped paths that no filing contains. Group paths mapped as leaves (the
+	// "" table field) count as seen when the group occurs with children.
+	for _, key := range keys {
+		for i := range key.path {
+			if key.path[i] == '.' || key.path[i] == '@' {
+				seen[key.path[:i]] = true
+			}
+		}
+	}
+	var neverSeen []string
+	for path := range mapped {
+		if !seen[path] {
+			neverSeen = append(neverSeen, path)
+		}
+	}
+	sort.Strings(neverSeen)
+	for _, path := range neverSeen {
+		row := []string{"never_seen", "", "", path, "0", "0", strings.Join(mapped[path], " ")}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush report: %w", err)
+	}
+
+	groups := make([][2]string, 0, len(d.filings))
+	for group := range d.filings {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i][0] != groups[j][0] {
+			return groups[i][0] < groups[j][0]
+		}
+		return groups[i][1] < groups[j][1]
+	})
+
+	fmt.Printf("\n%-10s %-12s %10s %10s %10s\n", "Return", "Version", "Filings", "Mapped", "Unmapped")
+	for _, group := range groups {
+		s := summaries[group]
+		if s == nil {
+			s = &summary{}
+		}
+		fmt.Printf("%-10s %-12s %10d %10d %10d\n", group[0], group[1], d.filings[group], s.mapped, s.unmapped)
+	}
+	fmt.Printf("\nMapped paths never seen: %d\n", len(neverSeen))
+
+	return nil
+}
+
+// DiscoverAllDirectories scans all extracted directories and writes the path report
+func DiscoverAllDirectories() error {
+	discoverer := NewPathDiscoverer()
+
+	baseDir := "data/990_zips"
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to read base directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dirPath := filepath.Join(baseDir, entry.Name())
+		log.Printf("Scanning directory: %s", dirPath)
+
+		if err := discoverer.ProcessDirectory(dirPath); err != nil {
+			log.Printf("Error scanning directory %s: %v", dirPath, err)
+			continue
+		}
+	}
+
+	log.Printf("Scan complete. Total files scanned: %d", discoverer.processed.Load())
+	return discoverer.WriteReport(discoverOutput)
+}
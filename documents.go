@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// Document inventory and references. Every child of ReturnData is a document:
+// the main form, its schedules and dependency statements. Forms, schedules and
+// lines point to statements through referenceDocumentId (a space separated
+// list of the documentId attributes of other documents), one row per reference.
+
+var documentsTable = tableSpec{
+	name: "documents",
+	columns: []string{
+		"Sequence",
+		"Document",
+		"Kind",
+		"Schedule",
+		"DocumentId",
+		"SoftwareId",
+		"SoftwareVersion",
+		"DocumentCount",
+		"BinaryAttachmentCount",
+	},
+	shared: map[string]string{
+		"Return.ReturnData@documentCnt":           "DocumentCount",
+		"Return.ReturnHeader@binaryAttachmentCnt": "BinaryAttachmentCount",
+	},
+}
+
+var documentReferencesTable = tableSpec{
+	name: "document_references",
+	columns: []string{
+		"Document",
+		"DocumentId",
+		"ElementPath",
+		"ReferenceDocumentId",
+		"ReferenceDocumentName",
+		"ReferencedDocument",
+	},
+}
+
+// documentCollector follows the documents of ReturnData while a file is decoded
+type documentCollector struct {
+	names    map[string]string // documentId -> document element name
+	current  string            // documentId of the open document
+	document map[string]string // inventory row of the open document
+	count    int
+}
+
+// start returns the inventory row of a document when one opens
+func (d *documentCollector) start(pathStack []string) map[string]string {
+	if len(pathStack) != 3 || pathStack[1] != "ReturnData" {
+		return nil
+	}
+
+	d.count++
+	d.current = ""
+	name := pathStack[2]
+	d.document = map[string]string{
+		"Sequence": strconv.Itoa(d.count),
+		"Document": name,
+		"Kind":     "statement",
+	}
+	switch {
+	case strings.HasPrefix(name, "IRS990Schedule"):
+		d.document["Kind"] = "schedule"
+		d.document["Schedule"] = strings.TrimPrefix(name, "IRS990Schedule")
+	case strings.HasPrefix(name, "IRS"):
+		d.document["Kind"] = "form"
+	}
+	return d.document
+}
+
+// attrs remembers document ids and returns one row per referenced document id
+func (d *documentCollector) attrs(pathStack []string, attrs []xml.Attr) []map[string]string {
+	if len(pathStack) < 3 || pathStack[1] != "ReturnData" {
+		return nil
+	}
+
+	var documentID, referenceIDs, referenceNames string
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "documentId":
+			documentID = attr.Value
+		case "referenceDocumentId":
+			referenceIDs = attr.Value
+		case "referenceDocumentName":
+			referenceNames = attr.Value
+		}
+	}
+	if len(pathStack) == 3 {
+		d.current = documentID
+		if documentID != "" {
+			d.names[documentID] = pathStack[2]
+		}
+		for _, attr := range attrs {
+			switch attr.Name.Local {
+			case "softwareId":
+				d.document["SoftwareId"] = attr.Value
+			case "softwareVersionNum":
+				d.document["SoftwareVersion"] = attr.Value
+			}
+		}
+		d.document["DocumentId"] = documentID
+	}
+
+	ids := strings.Fields(referenceIDs)
+	names := strings.Fields(referenceNames)
+	var rows []map[string]string
+	for i, id := range ids {
+		row := map[string]string{
+			"Document":            pathStack[2],
+			"DocumentId":          d.current,
+			"ElementPath":         strings.Join(pathStack, "."),
+			"ReferenceDocumentId": id,
+		}
+		// Names pair with ids only when both lists have the same length
+		if len(names) == len(ids) {
+			row["ReferenceDocumentName"] = names[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// summarizeDocuments sets the ScheduleA-ScheduleR flags of the main record from
+// the inventory and resolves each reference to the element name of the document
+// it points to. References to documents outside the public XML (binary
+// attachments) stay empty.
+func (p *XMLToCSVProcessor) summarizeDocuments(tables *tableTracker, record []string) {
+	for _, letter := range "ABCDEFGHIJKLMNOR" {
+		if idx, ok := p.fieldMap["Schedule"+string(letter)]; ok {
+			record[idx] = "false"
+		}
+	}
+	for _, row := range tables.rows[documentsTable.name] {
+		if idx, ok := p.fieldMap["Schedule"+row["Schedule"]]; ok && row["Schedule"] != "" {
+			record[idx] = "true"
+		}
+	}
+
+	for _, row := range tables.rows[documentReferencesTable.name] {
+		row["ReferencedDocument"] = tables.documents.names[row["ReferenceDocumentId"]]
+	}
+}
+
+// isNamespaceAttr reports whether attr is a namespace declaration rather than filing data
+func isNamespaceAttr(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns"
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Lossless long-format export. Every leaf element and attribute of a filing
+// becomes one (ObjectID, EIN, TaxYear, ElementPath, Value) row, so fields
+// outside the fixed CSV header can be pivoted downstream.
+//
+// ElementPath is the dot-joined path from Return. Elements that occur more
+// than once under the same parent carry a 1-based index ("...Grp[2].PersonNm"),
+// attributes are appended with @ ("Return.ReturnData.IRS990@documentId").
+
+var eavHeader = []string{"ObjectID", "EIN", "TaxYear", "ElementPath", "Value"}
+
+// EAVExporter writes the long-format export
+type EAVExporter struct {
+	outputFile *os.File
+	csvWriter  *csv.Writer
+	processed  atomic.Int64
+}
+
+// eavNode is an element of the filing being exported
+type eavNode struct {
+	parent   *eavNode
+	name     string
+	ordinal  int            // 1-based position among siblings with the same name
+	children map[string]int // child name -> occurrences so far
+}
+
+// eavValue is a leaf text or attribute value waiting for its path
+type eavValue struct {
+	node  *eavNode
+	attr  string
+	value string
+}
+
+// NewEAVExporter creates a new exporter
+func NewEAVExporter(outputPath string) (*EAVExporter, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(eavHeader); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	writer.Flush()
+
+	return &EAVExporter{
+		outputFile: file,
+		csvWriter:  writer,
+	}, nil
+}
+
+// Close closes the exporter and flushes data
+func (e *EAVExporter) Close() error {
+	e.csvWriter.Flush()
+	return e.outputFile.Close()
+}
+
+// ProcessDirectory exports all XML files in a directory
+func (e *EAVExporter) ProcessDirectory(dirPath string) error {
+	return processXMLFiles(dirPath, e.exportXMLFile)
+}
+
+// exportXMLFile reads a single XML file and returns the step that writes its
+// rows. Values are buffered until the end of the filing because repeat indices
+// depend on the siblings that follow.
+func (e *EAVExporter) exportXMLFile(filePath string) (func() error, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	values, err := collectEAVValues(xml.NewDecoder(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	rows := make([][]string, 0, len(values))
+	var ein, taxYear string
+	for _, v := range values {
+		path := v.node.path(true)
+		if v.attr != "" {
+			path += "@" + v.attr
+		} else {
+			switch v.node.path(false) {
+			case "Return.ReturnHeader.Filer.EIN":
+				ein = v.value
+			case "Return.ReturnHeader.TaxYr":
+				taxYear = v.value
+			}
+		}
+		rows = append(rows, []string{"", "", "", path, v.value})
+	}
+
+	objectID := objectIDFromFileName(filepath.Base(filePath))
+	for _, row := range rows {
+		row[0], row[1], row[2] = objectID, ein, taxYear
+	}
+
+	return func() error {
+		if err := e.csvWriter.WriteAll(rows); err != nil {
+			return fmt.Errorf("failed to write rows: %w", err)
+		}
+
+		processed := e.processed.Add(1)
+		if processed%1000 == 0 {
+			log.Printf("Exported %d files", processed)
+		}
+
+		return nil
+	}, nil
+}
+
+// collectEAVValues reads every attribute and non-empty element text in document order
+func collectEAVValues(decoder *xml.Decoder) ([]eavValue, error) {
+	var values []eavValue
+	var current *eavNode
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &eavNode{parent: current, name: t.Name.Local, ordinal: 1}
+			if current != nil {
+				current.children[node.name]++
+				node.ordinal = current.children[node.name]
+			}
+			node.children = make(map[string]int)
+			current = node
+			text.Reset()
+
+			for _, attr := range t.Attr {
+				if isNamespaceAttr(attr) {
+					continue
+				}
+				values = append(values, eavValue{node: node, attr: attr.Name.Local, value: attr.Value})
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			if current == nil {
+				continue
+			}
+			if value := strings.TrimSpace(text.String()); value != "" {
+				values = append(values, eavValue{node: current, value: value})
+			}
+			text.Reset()
+			current = current.parent
+		}
+	}
+}
+
+// path joins the element names from the root, with repeat indices if indexed
+func (n *eavNode) path(indexed bool) string {
+	var parts []string
+	for node := n; node != nil; node = node.parent {
+		part := node.name
+		if indexed && node.parent != nil && node.parent.children[node.name] > 1 {
+			part += "[" + strconv.Itoa(node.ordinal) + "]"
+		}
+		parts = append(parts, part)
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, ".")
+}
+
+// objectIDFromFileName strips the suffix from e-file names such as
+// 202401234567890123_public.xml
+func objectIDFromFileName(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if i := strings.Index(name, "_"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// ExportAllDirectories writes the long-format export of all extracted directories
+func ExportAllDirectories() error {
+	exporter, err := NewEAVExporter("irs_990_data_eav.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create exporter: %w", err)
+	}
+	defer exporter.Close()
+
+	baseDir := "data/990_zips"
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to read base directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dirPath := filepath.Join(baseDir, entry.Name())
+		log.Printf("Exporting directory: %s", dirPath)
+
+		if err := exporter.ProcessDirectory(dirPath); err != nil {
+			log.Printf("Error exporting directory %s: %v", dirPath, err)
+			continue
+		}
+	}
+
+	log.Printf("Export complete. Total files exported: %d", exporter.processed.Load())
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IRS Publication 78 (organizations eligible to receive tax-deductible
+// contributions) and the automatic revocation of exemption list. sync keeps
+// both downloads in a monthly snapshot directory, data/eo_eligibility/YYYY-MM.
+// Filings get the current status, from the latest snapshot holding each list,
+// which is what eligibility checks ask for, plus RevokedAtPeriodEnd from the
+// revocation and reinstatement dates. The search command shows every snapshot.
+
+const (
+	eligibilityDir     = "data/eo_eligibility"
+	eligibilityBaseURL = "https://apps.irs.gov/pub/epostcard/"
+	pub78File          = "data-download-pub78.zip"
+	revocationFile     = "data-download-revocation.zip"
+)
+
+// eligibilityColumns are the main CSV and panel columns joined from the lists
+var eligibilityColumns = []string{
+	"Pub78Snapshot",
+	"Pub78Eligible",
+	"Pub78Deductibility",
+	"RevocationSnapshot",
+	"AutoRevoked",
+	"RevocationDate",
+	"RevocationPostingDate",
+	"ReinstatementDate",
+	"RevokedAtPeriodEnd",
+}
+
+// revocationDateLayout is the date form of the revocation list, e.g. 15-MAY-2011
+const revocationDateLayout = "02-Jan-2006"
+
+// eligibilitySnapshot holds the listed EINs of one snapshot. The snapshot
+// date of a list is empty when the snapshot lacks it, its status is unknown.
+type eligibilitySnapshot struct {
+	date               string
+	pub78Snapshot      string
+	revocationSnapshot string
+	pub78              map[string]string       // EIN -> deductibility codes
+	revocations        map[string][]revocation // EIN -> revocations, oldest first
+}
+
+// revocation is one row of the revocation list. An organization revoked again
+// after a reinstatement has a row per revocation.
+type revocation struct {
+	date          string
+	postingDate   string
+	reinstatement string
+}
+
+// readEligibility reads every snapshot under dir, oldest first, keeping the
+// EINs in eins
+func readEligibility(dir string, eins map[string]bool) ([]eligibilitySnapshot, error) {
+	dirs, err := snapshotDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []eligibilitySnapshot
+	for _, snapshotDir := range dirs {
+		s := eligibilitySnapshot{
+			date:        filepath.Base(snapshotDir),
+			pub78:       make(map[string]string),
+			revocations: make(map[string][]revocation),
+		}
+
+		path := filepath.Join(snapshotDir, pub78File)
+		if _, err := os.Stat(path); err == nil {
+			// EIN|Name|City|State|Country|Deductibility codes
+			err := readEligibilityFile(path, func(row []string) {
+				if len(row) >= 6 && eins[row[0]] {
+					s.pub78[row[0]] = row[5]
+				}
+			})
+			if err != nil {
+				log.Printf("Error reading %s: %v", path, err)
+			} else {
+				s.pub78Snapshot = s.date
+			}
+		}
+
+		path = filepath.Join(snapshotDir, revocationFile)
+		if _, err := os.Stat(path); err == nil {
+			// EIN|Name|DBA|Address|City|State|ZIP|Country|Exemption type|
+			// Revocation date|Posting date|Reinstatement date
+			err := readEligibilityFile(path, func(row []string) {
+				if len(row) >= 11 && eins[row[0]] {
+					r := revocation{
+						date:        normalizeRevocationDate(row[9]),
+						postingDate: normalizeRevocationDate(row[10]),
+					}
+					if len(row) >= 12 {
+						r.reinstatement = normalizeRevocationDate(row[11])
+					}
+					s.revocations[row[0]] = append(s.revocations[row[0]], r)
+				}
+			})
+			if err != nil {
+				log.Printf("Error reading %s: %v", path, err)
+			} else {
+				s.revocationSnapshot = s.date
+			}
+			for _, rs := range s.revocations {
+				sort.Slice(rs, func(i, j int) bool { return rs[i].date < rs[j].date })
+			}
+		}
+
+		if s.pub78Snapshot != "" || s.revocationSnapshot != "" {
+			snapshots = append(snapshots, s)
+		}
+	}
+	return snapshots, nil
+}
+
+// readEligibilityFile passes every row of the pipe delimited text file in a
+// downloaded zip to read, with fields trimmed
+func readEligibilityFile(path string, read func(row []string)) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		defer rc.Close()
+
+		reader := csv.NewReader(rc)
+		reader.Comma = '|'
+		reader.LazyQuotes = true
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", file.Name, err)
+			}
+			for i := range row {
+				row[i] = strings.TrimSpace(row[i])
+			}
+			read(row)
+		}
+	}
+	return fmt.Errorf("zip has no data file")
+}
+
+// normalizeRevocationDate writes a revocation list date as YYYY-MM-DD
+func normalizeRevocationDate(value string) string {
+	if t, err := time.Parse(revocationDateLayout, value); err == nil {
+		return t.Format("2006-01-02")
+	}
+	return value
+}
+
+// status returns the eligibility columns of an EIN in the snapshot. The dates
+// are those of the latest revocation, RevokedAtPeriodEnd checks all of them.
+func (s eligibilitySnapshot) status(ein, taxPeriodEnd string) map[string]string {
+	values := make(map[string]string)
+	if s.pub78Snapshot != "" {
+		codes, listed := s.pub78[ein]
+		values["Pub78Snapshot"] = s.pub78Snapshot
+		values["Pub78Eligible"] = strconv.FormatBool(listed)
+		values["Pub78Deductibility"] = codes
+	}
+	if s.revocationSnapshot != "" {
+		rs := s.revocations[ein]
+		values["RevocationSnapshot"] = s.revocationSnapshot
+		values["AutoRevoked"] = strconv.FormatBool(len(rs) > 0)
+		if len(rs) > 0 {
+			latest := rs[len(rs)-1]
+			values["RevocationDate"] = latest.date
+			values["RevocationPostingDate"] = latest.postingDate
+			values["ReinstatementDate"] = latest.reinstatement
+		}
+		if taxPeriodEnd != "" {
+			values["RevokedAtPeriodEnd"] = strconv.FormatBool(revokedAt(rs, taxPeriodEnd))
+		}
+	}
+	return values
+}
+
+// revokedAt reports whether one of the revocations covers date: revoked on or
+// before it and not reinstated by then
+func revokedAt(rs []revocation, date string) bool {
+	for _, r := range rs {
+		if r.date <= date && (r.reinstatement == "" || r.reinstatement > date) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadEligibility returns the eligibility reference set of the EINs in eins
+func loadEligibility(dir string, eins map[string]bool) (referenceSet, error) {
+	set := referenceSet{name: "Pub 78 and revocation list"}
+
+	snapshots, err := readEligibility(dir, eins)
+	if err != nil {
+		return set, err
+	}
+	if len(snapshots) == 0 {
+		return set, nil
+	}
+
+	// Each list from the latest snapshot that has it
+	var current eligibilitySnapshot
+	for _, s := range snapshots {
+		if s.pub78Snapshot != "" {
+			current.pub78Snapshot, current.pub78 = s.pub78Snapshot, s.pub78
+		}
+		if s.revocationSnapshot != "" {
+			current.revocationSnapshot, current.revocations = s.revocationSnapshot, s.revocations
+		}
+	}
+	for ein := range eins {
+		if _, ok := current.pub78[ein]; ok {
+			set.found++
+		} else if _, ok := current.revocations[ein]; ok {
+			set.found++
+		}
+	}
+	set.lookup = current.status
+	return set, nil
+}
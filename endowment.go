@@ -0,0 +1,158 @@
+package main
+
+// Schedule D supplemental financial statements: Part I donor advised funds,
+// Part V five-year endowment history, Part VII/VIII investment detail and the
+// Part XI/XII reconciliation of revenue and expenses to the audited financial
+// statements. Part XIII explanations land in the narrative table.
+
+const scheduleDPath = "Return.ReturnData.IRS990ScheduleD."
+
+// scheduleDFundsTable has one row per Schedule D, the group is the schedule itself
+var scheduleDFundsTable = tableSpec{
+	name: "schedule_d_funds",
+	columns: []string{
+		"DonorAdvisedFundsHeld",
+		"OtherFundsHeld",
+		"DonorAdvisedFundsContributions",
+		"OtherFundsContributions",
+		"DonorAdvisedFundsGrants",
+		"OtherFundsGrants",
+		"DonorAdvisedFundsValueEOY",
+		"OtherFundsValueEOY",
+		"DisclosedLegalControl",
+		"DisclosedCharitablePurpose",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnData.IRS990ScheduleD"},
+	},
+	fields: map[string]string{
+		"DonorAdvisedFundsHeldCnt":       "DonorAdvisedFundsHeld",
+		"FundsAndOtherAccountsHeldCnt":   "OtherFundsHeld",
+		"DonorAdvisedFundsContriAmt":     "DonorAdvisedFundsContributions",
+		"FundsAndOtherAccountsContriAmt": "OtherFundsContributions",
+		"DonorAdvisedFundsGrantsAmt":     "DonorAdvisedFundsGrants",
+		"FundsAndOtherAccountsGrantsAmt": "OtherFundsGrants",
+		"DonorAdvisedFundsVlEOYAmt":      "DonorAdvisedFundsValueEOY",
+		"FundsAndOtherAccountsVlEOYAmt":  "OtherFundsValueEOY",
+		"DisclosedOrgLegalCtrlInd":       "DisclosedLegalControl",
+		"DisclosedForCharitablePrpsInd":  "DisclosedCharitablePurpose",
+	},
+}
+
+var scheduleDEndowmentTable = tableSpec{
+	name: "schedule_d_endowment",
+	columns: []string{
+		"YearsBack",
+		"BeginningBalance",
+		"Contributions",
+		"InvestmentEarnings",
+		"GrantsOrScholarships",
+		"OtherExpenditures",
+		"AdministrativeExpenses",
+		"EndBalance",
+		// Part V lines 2 and 3, repeated on every row of the filing
+		"BoardDesignatedPct",
+		"PermanentEndowmentPct",
+		"TermEndowmentPct",
+		"HeldByUnrelatedOrgs",
+		"HeldByRelatedOrgs",
+	},
+	groups: []tableGroup{
+		{path: scheduleDPath + "CYEndwmtFundGrp", values: map[string]string{"YearsBack": "0"}},
+		{path: scheduleDPath + "CYMinus1YrEndwmtFundGrp", values: map[string]string{"YearsBack": "1"}},
+		{path: scheduleDPath + "CYMinus2YrEndwmtFundGrp", values: map[string]string{"YearsBack": "2"}},
+		{path: scheduleDPath + "CYMinus3YrEndwmtFundGrp", values: map[string]string{"YearsBack": "3"}},
+		{path: scheduleDPath + "CYMinus4YrEndwmtFundGrp", values: map[string]string{"YearsBack": "4"}},
+	},
+	fields: map[string]string{
+		"BeginningYearBalanceAmt":       "BeginningBalance",
+		"ContributionsAmt":              "Contributions",
+		"InvestmentEarningsOrLossesAmt": "InvestmentEarnings",
+		"GrantsOrScholarshipsAmt":       "GrantsOrScholarships",
+		"OtherExpendituresAmt":          "OtherExpenditures",
+		"AdministrativeExpensesAmt":     "AdministrativeExpenses",
+		"EndYearBalanceAmt":             "EndBalance",
+	},
+	shared: map[string]string{
+		scheduleDPath + "BoardDesignatedBalanceEOYPct":  "BoardDesignatedPct",
+		scheduleDPath + "PrmnntEndowmentBalanceEOYPct":  "PermanentEndowmentPct",
+		scheduleDPath + "TermEndowmentBalanceEOYPct":    "TermEndowmentPct",
+		scheduleDPath + "EndowmentsHeldUnrelatedOrgInd": "HeldByUnrelatedOrgs",
+		scheduleDPath + "EndowmentsHeldRelatedOrgInd":   "HeldByRelatedOrgs",
+	},
+}
+
+var scheduleDInvestmentsTable = tableSpec{
+	name: "schedule_d_investments",
+	columns: []string{
+		"Part",
+		"Category",
+		"Description",
+		"BookValue",
+		"ValuationMethod",
+	},
+	groups: []tableGroup{
+		{path: scheduleDPath + "FinancialDerivativesGrp", values: map[string]string{"Part": "VII", "Category": "financial_derivatives"}},
+		{path: scheduleDPath + "CloselyHeldEquityInterestsGrp", values: map[string]string{"Part": "VII", "Category": "closely_held_equity"}},
+		{path: scheduleDPath + "OtherSecuritiesGrp", values: map[string]string{"Part": "VII", "Category": "other_securities"}},
+		{path: scheduleDPath + "TotalBookValueSecuritiesAmt", values: map[string]string{"Part": "VII", "Category": "total"}},
+		{path: scheduleDPath + "InvestmentProgramRelatedGrp", values: map[string]string{"Part": "VIII", "Category": "program_related"}},
+		{path: scheduleDPath + "TotalBookValueProgramRelatedAmt", values: map[string]string{"Part": "VIII", "Category": "total"}},
+	},
+	fields: map[string]string{
+		"":                  "BookValue", // part totals
+		"Desc":              "Description",
+		"BookValueAmt":      "BookValue",
+		"MethodValuationCd": "ValuationMethod",
+	},
+}
+
+// Part XI and XII lines are single amounts. Line 4a (investment expenses not
+// on Part VIII line 7b) is one element shared by both parts and kept under XI.
+var scheduleDReconciliationTable = tableSpec{
+	name: "schedule_d_reconciliation",
+	columns: []string{
+		"Part",
+		"Line",
+		"LineId",
+		"Amount",
+	},
+	groups: append(
+		partGroups("XI", lineGroups(scheduleDPath, []formLine{
+			{"1", "revenue_per_audited_statements", "TotalRevEtcAuditedFinclStmtAmt"},
+			{"2a", "net_unrealized_gains", "NetUnrlzdGainsLossesInvstAmt"},
+			{"2b", "donated_services_revenue", "DonatedServicesAndUseFcltsAmt"},
+			{"2c", "recoveries_prior_year_grants", "RecoveriesPriorYearGrantsAmt"},
+			{"2d", "other_revenue_adjustments", "OtherRevenueAmt"},
+			{"2e", "revenue_adjustments_total", "RevenueNotReportedAmt"},
+			{"3", "revenue_subtotal", "RevenueSubtotalAmt"},
+			{"4a", "investment_expenses_not_included", "InvestmentExpensesNotIncldAmt"},
+			{"4b", "other_revenue_not_included", "OtherRevenueNotIncldAmt"},
+			{"4c", "revenue_not_included_total", "RevenueNotIncldAmt"},
+			{"5", "revenue_per_return", "TotalRevenuePerForm990Amt"},
+		})),
+		partGroups("XII", lineGroups(scheduleDPath, []formLine{
+			{"1", "expenses_per_audited_statements", "TotExpnsEtcAuditedFinclStmtAmt"},
+			{"2a", "donated_services_expenses", "DonatedServicesAndUseFcltsExpnsAmt"},
+			{"2b", "prior_year_adjustments", "PriorYearAdjustmentsAmt"},
+			{"2c", "other_losses", "OtherLossesAmt"},
+			{"2d", "other_expense_adjustments", "OtherExpensesAmt"},
+			{"2e", "expense_adjustments_total", "ExpensesNotReportedAmt"},
+			{"3", "expenses_subtotal", "ExpensesSubtotalAmt"},
+			{"4b", "other_expenses_not_included", "OtherExpensesIncludedAmt"},
+			{"4c", "expenses_not_included_total", "ExpensesNotIncldAmt"},
+			{"5", "expenses_per_return", "TotalExpensesPerForm990Amt"},
+		}))...,
+	),
+	fields: map[string]string{
+		"": "Amount",
+	},
+}
+
+// partGroups tags line groups with the part they belong to
+func partGroups(part string, groups []tableGroup) []tableGroup {
+	for _, g := range groups {
+		g.values["Part"] = part
+	}
+	return groups
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Form 990 financial statements: Part VIII (revenue), Part IX (functional
+// expenses) and Part X (balance sheet) as long-format tables with one row per
+// form line.
+
+const form990Path = "Return.ReturnData.IRS990."
+
+// formLine identifies one line of a form part
+type formLine struct {
+	line    string // line number as printed on the form
+	id      string // stable identifier, independent of schema element names
+	element string // element name under the form
+}
+
+// lineGroups turns form lines into table groups carrying Line and LineId
+func lineGroups(base string, lines []formLine) []tableGroup {
+	groups := make([]tableGroup, 0, len(lines))
+	for _, l := range lines {
+		groups = append(groups, tableGroup{
+			path:   base + l.element,
+			values: map[string]string{"Line": l.line, "LineId": l.id},
+		})
+	}
+	return groups
+}
+
+var partIXLines = []formLine{
+	{"1", "grants_domestic_orgs", "GrantsToDomesticOrgsGrp"},
+	{"2", "grants_domestic_individuals", "GrantsToDomesticIndividualsGrp"},
+	{"3", "grants_foreign", "ForeignGrantsGrp"},
+	{"4", "benefits_to_members", "BenefitsToMembersGrp"},
+	{"5", "comp_officers_directors", "CompCurrentOfcrDirectorsGrp"},
+	{"6", "comp_disqualified_persons", "CompDisqualPersonsGrp"},
+	{"7", "other_salaries_wages", "OtherSalariesAndWagesGrp"},
+	{"8", "pension_plan_contributions", "PensionPlanContributionsGrp"},
+	{"9", "other_employee_benefits", "OtherEmployeeBenefitsGrp"},
+	{"10", "payroll_taxes", "PayrollTaxesGrp"},
+	{"11a", "fees_management", "FeesForServicesManagementGrp"},
+	{"11b", "fees_legal", "FeesForServicesLegalGrp"},
+	{"11c", "fees_accounting", "FeesForServicesAccountingGrp"},
+	{"11d", "fees_lobbying", "FeesForServicesLobbyingGrp"},
+	{"11e", "fees_professional_fundraising", "FeesForServicesProfFundraising"},
+	{"11f", "fees_investment_management", "FeesForSrvcInvstMgmntFeesGrp"},
+	{"11g", "fees_other", "FeesForServicesOtherGrp"},
+	{"12", "advertising_promotion", "AdvertisingGrp"},
+	{"13", "office_expenses", "OfficeExpensesGrp"},
+	{"14", "information_technology", "InformationTechnologyGrp"},
+	{"15", "royalties", "RoyaltiesGrp"},
+	{"16", "occupancy", "OccupancyGrp"},
+	{"17", "travel", "TravelGrp"},
+	{"18", "travel_entertainment_officials", "PymtTravelEntrtnmntPubOfclGrp"},
+	{"19", "conferences_meetings", "ConferencesMeetingsGrp"},
+	{"20", "interest", "InterestGrp"},
+	{"21", "payments_to_affiliates", "PaymentsToAffiliatesGrp"},
+	{"22", "depreciation_depletion", "DepreciationDepletionGrp"},
+	{"23", "insurance", "InsuranceGrp"},
+	{"24", "other_expenses", "OtherExpensesGrp"},
+	{"24e", "all_other_expenses", "AllOtherExpensesGrp"},
+	{"25", "total_functional_expenses", "TotalFunctionalExpensesGrp"},
+	{"26", "joint_costs", "JointCostsGrp"},
+}
+
+var partIXTable = tableSpec{
+	name: "part_ix",
+	columns: []string{
+		"Line",
+		"LineId",
+		"Description",
+		"Total",
+		"ProgramServices",
+		"ManagementAndGeneral",
+		"Fundraising",
+	},
+	groups: lineGroups(form990Path, partIXLines),
+	fields: map[string]string{
+		"Desc":                    "Description",
+		"TotalAmt":                "Total",
+		"ProgramServicesAmt":      "ProgramServices",
+		"ManagementAndGeneralAmt": "ManagementAndGeneral",
+		"FundraisingAmt":          "Fundraising",
+	},
+}
+
+// Part VIII lines reported as a single amount go to the Total column only.
+// Lines 6a-6c and 7a-7c have a row per sub-column (real and personal
+// property, securities and other assets), e.g. 6a_real and 6a_personal.
+var partVIIILines = []formLine{
+	{"1a", "federated_campaigns", "FederatedCampaignsAmt"},
+	{"1b", "membership_dues", "MembershipDuesAmt"},
+	{"1c", "fundraising_events", "FundraisingAmt"},
+	{"1d", "related_organizations", "RelatedOrganizationsAmt"},
+	{"1e", "government_grants", "GovernmentGrantsAmt"},
+	{"1f", "all_other_contributions", "AllOtherContributionsAmt"},
+	{"1g", "noncash_contributions", "NoncashContributionsAmt"},
+	{"1h", "total_contributions", "TotalContributionsAmt"},
+	{"2", "program_service_revenue", "ProgramServiceRevenueGrp"},
+	{"2f", "other_program_service_revenue", "TotalOthProgramServiceRevenueGrp"},
+	{"2g", "total_program_service_revenue", "TotalProgramServiceRevenueAmt"},
+	{"3", "investment_income", "InvestmentIncomeGrp"},
+	{"4", "tax_exempt_bond_proceeds", "IncmFromInvestBondProceedsGrp"},
+	{"5", "royalties", "RoyaltiesRevenueGrp"},
+	{"6a_real", "gross_rents_real", "GrossRentsGrp.RealAmt"},
+	{"6a_personal", "gross_rents_personal", "GrossRentsGrp.PersonalAmt"},
+	{"6b_real", "rental_expenses_real", "LessRentalExpensesGrp.RealAmt"},
+	{"6b_personal", "rental_expenses_personal", "LessRentalExpensesGrp.PersonalAmt"},
+	{"6c_real", "rental_income_real", "RentalIncomeOrLossGrp.RealAmt"},
+	{"6c_personal", "rental_income_personal", "RentalIncomeOrLossGrp.PersonalAmt"},
+	{"6d", "net_rental_income", "NetRentalIncomeOrLossGrp"},
+	{"7a_securities", "gross_sales_assets_securities", "GrossAmountSalesAssetsGrp.SecuritiesAmt"},
+	{"7a_other", "gross_sales_assets_other", "GrossAmountSalesAssetsGrp.OtherAmt"},
+	{"7b_securities", "cost_basis_sales_expenses_securities", "LessCostOthBasisSalesExpnssGrp.SecuritiesAmt"},
+	{"7b_other", "cost_basis_sales_expenses_other", "LessCostOthBasisSalesExpnssGrp.OtherAmt"},
+	{"7c_securities", "gain_or_loss_securities", "GainOrLossGrp.SecuritiesAmt"},
+	{"7c_other", "gain_or_loss_other", "GainOrLossGrp.OtherAmt"},
+	{"7d", "net_gain_sale_of_assets", "NetGainOrLossInvestmentsGrp"},
+	{"8a", "fundraising_gross_income", "FundraisingGrossIncomeAmt"},
+	{"8b", "fundraising_direct_expenses", "FundraisingDirectExpensesAmt"},
+	{"8c", "net_fundraising_events", "NetIncmFromFundraisingEvtGrp"},
+	{"9a", "gaming_gross_income", "GamingGrossIncomeAmt"},
+	{"9b", "gaming_direct_expenses", "GamingDirectExpensesAmt"},
+	{"9c", "net_gaming", "NetIncomeFromGamingGrp"},
+	{"10a", "gross_sales_inventory", "GrossSalesOfInventoryAmt"},
+	{"10b", "cost_of_goods_sold", "CostOfGoodsSoldAmt"},
+	{"10c", "net_sales_inventory", "NetIncomeOrLossGrp"},
+	{"11", "other_revenue", "OtherRevenueMiscGrp"},
+	{"11e", "total_other_revenue", "OtherRevenueTotalAmt"},
+	{"12", "total_revenue", "TotalRevenueGrp"},
+}
+
+var partVIIITable = tableSpec{
+	name: "part_viii",
+	columns: []string{
+		"Line",
+		"LineId",
+		"Description",
+		"BusinessCode",
+		"Total",
+		"RelatedOrExempt",
+		"Unrelated",
+		"Excluded",
+	},
+	groups: lineGroups(form990Path, partVIIILines),
+	fields: map[string]string{
+		"":                             "Total", // single amount lines
+		"Desc":                         "Description",
+		"BusinessCd":                   "BusinessCode",
+		"TotalRevenueColumnAmt":        "Total",
+		"RelatedOrExemptFuncIncomeAmt": "RelatedOrExempt",
+		"UnrelatedBusinessRevenueAmt":  "Unrelated",
+		"ExclusionAmt":                 "Excluded",
+	},
+}
+
+// Part X line numbers follow the 2018+ form; lines retired by the net asset
+// reporting change keep their pre-2018 numbers
+var partXLines = []formLine{
+	{"1", "cash_non_interest_bearing", "CashNonInterestBearingGrp"},
+	{"2", "savings_temporary_investments", "SavingsAndTempCashInvstGrp"},
+	{"3", "pledges_grants_receivable", "PledgesAndGrantsReceivableGrp"},
+	{"4", "accounts_receivable", "AccountsReceivableGrp"},
+	{"5", "receivables_from_officers", "ReceivablesFromOfficersEtcGrp"},
+	{"6", "receivables_from_disqualified_persons", "RcvblFromDisqualifiedPrsnGrp"},
+	{"7", "other_notes_loans_receivable", "OthNotesLoansReceivableNetGrp"},
+	{"8", "inventories", "InventoriesForSaleOrUseGrp"},
+	{"9", "prepaid_expenses", "PrepaidExpensesDefrdChargesGrp"},
+	{"10a", "land_buildings_equipment_cost", "LandBldgEquipCostOrOtherBssAmt"},
+	{"10b", "land_buildings_equipment_depreciation", "LandBldgEquipAccumDeprecAmt"},
+	{"10c", "land_buildings_equipment_net", "LandBldgEquipBasisNetGrp"},
+	{"11", "investments_publicly_traded", "InvestmentsPubTradedSecGrp"},
+	{"12", "investments_other_securities", "InvestmentsOtherSecuritiesGrp"},
+	{"13", "investments_program_related", "InvestmentsProgramRelatedGrp"},
+	{"14", "intangible_assets", "IntangibleAssetsGrp"},
+	{"15", "other_assets", "OtherAssetsTotalGrp"},
+	{"16", "total_assets", "TotalAssetsGrp"},
+	{"17", "accounts_payable", "AccountsPayableAccrExpnssGrp"},
+	{"18", "grants_payable", "GrantsPayableGrp"},
+	{"19", "deferred_revenue", "DeferredRevenueGrp"},
+	{"20", "tax_exempt_bonds", "TaxExemptBondLiabilitiesGrp"},
+	{"21", "escrow_liability", "EscrowAccountLiabilityGrp"},
+	{"22", "payables_to_officers", "LoansFromOfficersDirectorsGrp"},
+	{"23", "secured_mortgages_notes", "MortgNotesPyblScrdInvstPropGrp"},
+	{"24", "unsecured_notes_loans", "UnsecuredNotesLoansPayableGrp"},
+	{"25", "other_liabilities", "OtherLiabilitiesGrp"},
+	{"26", "total_liabilities", "TotalLiabilitiesGrp"},
+	{"27", "net_assets_without_donor_restrictions", "NoDonorRestrictionNetAssetsGrp"},
+	{"28", "net_assets_with_donor_restrictions", "DonorRestrictionNetAssetsGrp"},
+	{"27", "unrestricted_net_assets", "UnrestrictedNetAssetsGrp"},
+	{"28", "temporarily_restricted_net_assets", "TemporarilyRstrNetAssetsGrp"},
+	{"29", "permanently_restricted_net_assets", "PermanentlyRstrNetAssetsGrp"},
+	{"29", "capital_stock_trust_principal", "CapStkTrPrinCurrentFundsGrp"},
+	{"30", "paid_in_capital_surplus", "PdInCapSrplsLandBldgEqpFundGrp"},
+	{"31", "retained_earnings_endowment", "RtnEarnEndowmentIncmOthFndsGrp"},
+	{"32", "total_net_assets", "TotalNetAssetsFundBalanceGrp"},
+	{"33", "total_liabilities_net_assets", "TotLiabNetAssetsFundBalanceGrp"},
+}
+
+var partXTable = tableSpec{
+	name: "part_x",
+	columns: []string{
+		"Line",
+		"LineId",
+		"BOY",
+		"EOY",
+	},
+	groups: lineGroups(form990Path, partXLines),
+	fields: map[string]string{
+		"":       "EOY", // lines 10a and 10b are reported at end of year only
+		"BOYAmt": "BOY",
+		"EOYAmt": "EOY",
+	},
+}
+
+// balanceSheetColumns maps Part X lines to the <prefix>BOY/<prefix>EOY columns
+// of the main record
+var balanceSheetColumns = map[string]string{
+	"cash_non_interest_bearing": "Cash",
+	"other_assets":              "OtherAssets",
+	"total_assets":              "Assets",
+	"accounts_payable":          "AccountsPayable",
+	"grants_payable":            "GrantsPayable",
+	"tax_exempt_bonds":          "Bonds",
+	"payables_to_officers":      "PayablesToOfficers",
+	"secured_mortgages_notes":   "Mortgages",
+	"unsecured_notes_loans":     "NotesPayable",
+	"other_liabilities":         "OtherLiabilities",
+	"total_liabilities":         "Liabilities",
+	"total_net_assets":          "NetAssets",
+
+	"net_assets_without_donor_restrictions": "UnrestrictedNetAssets",
+	"unrestricted_net_assets":               "UnrestrictedNetAssets",
+}
+
+// balanceSheetSums are main record columns that add up several Part X lines.
+// TotalDebt is bonds, mortgages and notes; loans from officers and other
+// interested persons (line 22) are kept apart in PayablesToOfficers.
+var balanceSheetSums = map[string][]string{
+	"Investments": {"investments_publicly_traded", "investments_other_securities", "investments_program_related"},
+	"TotalDebt":   {"tax_exempt_bonds", "secured_mortgages_notes", "unsecured_notes_loans"},
+}
+
+// summarizeBalanceSheet fills the BOY/EOY columns of the main record from the
+// Part X rows and checks that assets equal liabilities plus net assets
+func (p *XMLToCSVProcessor) summarizeBalanceSheet(tables *tableTracker, record []string) {
+	lines := make(map[string]map[string]string)
+	for _, row := range tables.rows[partXTable.name] {
+		lines[row["LineId"]] = row
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	set := func(field, value string) {
+		if idx, ok := p.fieldMap[field]; ok && value != "" {
+			record[idx] = value
+		}
+	}
+
+	for _, year := range []string{"BOY", "EOY"} {
+		for lineID, prefix := range balanceSheetColumns {
+			if row, ok := lines[lineID]; ok {
+				set(prefix+year, row[year])
+			}
+		}
+
+		for field, lineIDs := range balanceSheetSums {
+			var total int64
+			var found bool
+			for _, lineID := range lineIDs {
+				if amount, ok := parseAmount(lines[lineID][year]); ok {
+					total += amount
+					found = true
+				}
+			}
+			if found {
+				set(field+year, strconv.FormatInt(total, 10))
+			}
+		}
+
+		assets, okA := parseAmount(lines["total_assets"][year])
+		liabilities, okL := parseAmount(lines["total_liabilities"][year])
+		netAssets, okN := parseAmount(lines["total_net_assets"][year])
+		if okA && okL && okN {
+			set("BalanceCheck"+year, strconv.FormatBool(assets == liabilities+netAssets))
+		}
+	}
+
+	if row, ok := lines["total_net_assets"]; ok {
+		set("NetAssets", row["EOY"])
+	}
+}
+
+// parseAmount parses a whole dollar amount, reporting false when it is missing
+// or not a number
+func parseAmount(value string) (int64, bool) {
+	amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+// summarizeFunctionalExpenses fills ProfessionalFees with the Part IX line 11
+// fees for services total, which has no single element of its own
+func (p *XMLToCSVProcessor) summarizeFunctionalExpenses(tables *tableTracker, record []string) {
+	idx, ok := p.fieldMap["ProfessionalFees"]
+	if !ok {
+		return
+	}
+
+	var total int64
+	var found bool
+	for _, row := range tables.rows[partIXTable.name] {
+		if !strings.HasPrefix(row["Line"], "11") {
+			continue
+		}
+		amount, ok := parseAmount(row["Total"])
+		if !ok {
+			continue
+		}
+		total += amount
+		found = true
+	}
+
+	if found {
+		record[idx] = strconv.FormatInt(total, 10)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+// Schedule F activities outside the United States: Part I regional activities
+// and Part II grants to foreign organizations, one row per region line.
+
+const scheduleFPath = "Return.ReturnData.IRS990ScheduleF."
+
+var scheduleFTable = tableSpec{
+	name: "schedule_f",
+	columns: []string{
+		"Part",
+		"Region",
+		"ActivityType",
+		"SpecificServices",
+		"Offices",
+		"Employees",
+		"Expenditures",
+		"GrantPurpose",
+		"CashGrant",
+		"DisbursementMethod",
+		"NonCashAssistance",
+		"NonCashDescription",
+		"ValuationMethod",
+	},
+	groups: []tableGroup{
+		{path: scheduleFPath + "AccountActivitiesOutsideUSGrp", values: map[string]string{"Part": "I"}},
+		{path: scheduleFPath + "GrantsToOrgOutsideUSGrp", values: map[string]string{"Part": "II"}},
+	},
+	fields: map[string]string{
+		"RegionTxt":                     "Region",
+		"TypeOfActivitiesConductedTxt":  "ActivityType",
+		"SpecificServicesProvidedTxt":   "SpecificServices",
+		"OfficesCnt":                    "Offices",
+		"EmployeeCnt":                   "Employees",
+		"RegionTotalExpendituresAmt":    "Expenditures",
+		"PurposeOfGrantTxt":             "GrantPurpose",
+		"CashGrantAmt":                  "CashGrant",
+		"MannerOfCashDisbursementTxt":   "DisbursementMethod",
+		"NonCashAssistanceAmt":          "NonCashAssistance",
+		"DescriptionOfNonCashAssistTxt": "NonCashDescription",
+		"ValuationMethodUsedDesc":       "ValuationMethod",
+	},
+}
@@ -0,0 +1,146 @@
+package main
+
+// Schedule H (hospitals): community benefit at cost (Part I line 7) and
+// community building activities (Part II), bad debt and Medicare figures
+// (Part III) and the Part V Section A hospital facility list.
+
+const scheduleHPath = "Return.ReturnData.IRS990ScheduleH."
+
+// communityBenefitGroups tags Part I and Part II lines with their part
+func communityBenefitGroups() []tableGroup {
+	benefits := partGroups("I", lineGroups(scheduleHPath, []formLine{
+		{"7a", "financial_assistance_at_cost", "FinancialAssistanceAtCostTyp"},
+		{"7b", "unreimbursed_medicaid", "UnreimbursedMedicaidGrp"},
+		{"7c", "unreimbursed_means_tested", "UnreimbursedCostsGrp"},
+		{"7d", "total_financial_assistance", "TotalFinancialAssistanceTyp"},
+		{"7e", "community_health_services", "CommunityHealthServicesGrp"},
+		{"7f", "health_professions_education", "HealthProfessionsEducationGrp"},
+		{"7g", "subsidized_health_services", "SubsidizedHealthServicesGrp"},
+		{"7h", "research", "ResearchGrp"},
+		{"7i", "cash_in_kind_contributions", "CashAndInKindContributionsGrp"},
+		{"7j", "total_other_benefits", "TotalOtherBenefitsGrp"},
+		{"7k", "total_community_benefits", "TotalCommunityBenefitsGrp"},
+	}))
+
+	building := partGroups("II", lineGroups(scheduleHPath, []formLine{
+		{"1", "physical_improvements_housing", "PhysicalImprovementsHousingGrp"},
+		{"2", "economic_development", "EconomicDevelopmentGrp"},
+		{"3", "community_support", "CommunitySupportGrp"},
+		{"4", "environmental_improvements", "EnvironmentalImprovementsGrp"},
+		{"5", "leadership_development", "LeadershipDevelopmentGrp"},
+		{"6", "coalition_building", "CoalitionBuildingGrp"},
+		{"7", "health_improvement_advocacy", "HealthImprovementAdvocacyGrp"},
+		{"8", "workforce_development", "WorkforceDevelopmentGrp"},
+		{"9", "other_community_building", "OtherCommuntityBuildingActyGrp"},
+		{"10", "total_community_building", "TotalCommuntityBuildingActyGrp"},
+	}))
+
+	return append(benefits, building...)
+}
+
+var scheduleHBenefitsTable = tableSpec{
+	name: "schedule_h_benefits",
+	columns: []string{
+		"Part",
+		"Line",
+		"LineId",
+		"ActivitiesOrPrograms",
+		"PersonsServed",
+		"TotalExpense",
+		"DirectOffsettingRevenue",
+		"NetExpense",
+		"PercentOfTotalExpense",
+	},
+	groups: communityBenefitGroups(),
+	fields: map[string]string{
+		"ActivitiesOrProgramsCnt":        "ActivitiesOrPrograms",
+		"PersonsServedCnt":               "PersonsServed",
+		"TotalCommunityBenefitExpnsAmt":  "TotalExpense",
+		"TotalCommunityBuildingExpnsAmt": "TotalExpense",
+		"DirectOffsettingRevenueAmt":     "DirectOffsettingRevenue",
+		"NetCommunityBenefitExpnsAmt":    "NetExpense",
+		"NetCommunityBuildingExpnsAmt":   "NetExpense",
+		"TotalExpensePct":                "PercentOfTotalExpense",
+	},
+}
+
+// scheduleHPartIIITable has one row per Schedule H, the group is the schedule itself
+var scheduleHPartIIITable = tableSpec{
+	name: "schedule_h_part_iii",
+	columns: []string{
+		"BadDebtReportedUnderHFMA15",
+		"BadDebtExpense",
+		"BadDebtAttributableToFinancialAssistance",
+		"MedicareReimbursement",
+		"MedicareAllowableCosts",
+		"MedicareSurplusOrShortfall",
+		"CostAccountingSystem",
+		"CostToChargeRatio",
+		"OtherCostingMethod",
+		"WrittenDebtCollectionPolicy",
+		"CollectionPolicyFinancialAssistance",
+		"FacilityCount",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnData.IRS990ScheduleH"},
+	},
+	fields: map[string]string{
+		"BadDebtExpenseReportedInd":        "BadDebtReportedUnderHFMA15",
+		"BadDebtExpenseAmt":                "BadDebtExpense",
+		"BadDebtExpenseAttributableAmt":    "BadDebtAttributableToFinancialAssistance",
+		"ReimbursedByMedicareAmt":          "MedicareReimbursement",
+		"CostOfCareReimbursedByMedcrAmt":   "MedicareAllowableCosts",
+		"MedicareSurplusOrShortfallAmt":    "MedicareSurplusOrShortfall",
+		"CostAccountingSystemInd":          "CostAccountingSystem",
+		"CostToChargeRatioInd":             "CostToChargeRatio",
+		"OtherMethodUsedInd":               "OtherCostingMethod",
+		"WrittenDebtCollectionPolicyInd":   "WrittenDebtCollectionPolicy",
+		"CollectionPolicyFinancialAsstInd": "CollectionPolicyFinancialAssistance",
+		"HospitalFacilitiesCnt":            "FacilityCount",
+	},
+}
+
+var scheduleHFacilitiesTable = tableSpec{
+	name: "schedule_h_facilities",
+	columns: []string{
+		"FacilityNumber",
+		"FacilityName",
+		"AddressLine1",
+		"City",
+		"State",
+		"ZIPCode",
+		"Website",
+		"StateLicenseNumber",
+		"ReportingGroup",
+		"LicensedHospital",
+		"GeneralMedicalSurgical",
+		"ChildrensHospital",
+		"TeachingHospital",
+		"CriticalAccessHospital",
+		"ResearchFacility",
+		"ER24Hours",
+		"EROther",
+	},
+	groups: []tableGroup{
+		{path: scheduleHPath + "HospitalFacilitiesGrp"},
+	},
+	fields: map[string]string{
+		"FacilityNum":                       "FacilityNumber",
+		"BusinessName.BusinessNameLine1Txt": "FacilityName",
+		"USAddress.AddressLine1Txt":         "AddressLine1",
+		"USAddress.CityNm":                  "City",
+		"USAddress.StateAbbreviationCd":     "State",
+		"USAddress.ZIPCd":                   "ZIPCode",
+		"WebsiteAddressTxt":                 "Website",
+		"StateLicenseNum":                   "StateLicenseNumber",
+		"FacilityReportingGroupCd":          "ReportingGroup",
+		"LicensedHospitalInd":               "LicensedHospital",
+		"GeneralMedicalAndSurgicalInd":      "GeneralMedicalSurgical",
+		"ChildrensHospitalInd":              "ChildrensHospital",
+		"TeachingHospitalInd":               "TeachingHospital",
+		"CriticalAccessHospitalInd":         "CriticalAccessHospital",
+		"ResearchFacilityInd":               "ResearchFacility",
+		"ER24HoursInd":                      "ER24Hours",
+		"EROtherInd":                        "EROther",
+	},
+}
@@ -3,6 +3,7 @@ package main
 import (
     "archive/zip"
     "bufio"
+    "flag"
     "fmt"
     "io"
     "log"
@@ -36,27 +37,38 @@ func confirmation(s string, tries int) (bool, error) {
 func printUsage() {
     fmt.Println("theIRS - IRS Form 990 Data Extraction Tool")
     fmt.Println()
-    fmt.Println("Usage: theIRS <command>")
+    fmt.Println("Usage: theIRS <command> [options]")
     fmt.Println()
     fmt.Println("Commands:")
     fmt.Println("  sync      Check and download missing ZIP files (recommended)")
     fmt.Println("  unzip     Extract all ZIP files to directories")
     fmt.Println("  csv       Process XML files and generate CSV output")
+    fmt.Println("  eav       Export every element and attribute as long-format rows")
+    fmt.Println("  discover  Report element paths found in the XML files and their mapping coverage")
+    fmt.Println("  search    Show the filings, BMF classification and Pub 78/revocation history of an EIN")
     fmt.Println("  schemas   Download and process XSD schema files (for developers)")
     fmt.Println("  zips      Download all ZIP files from scratch (deprecated, use sync)")
     fmt.Println("  help      Show this help message")
     fmt.Println()
+    fmt.Println("csv options:")
+    fmt.Println("  -provenance  Also write the source element path and match method of every value")
+    fmt.Println("  -latest      Keep only the authoritative version of amended or duplicate filings")
+    fmt.Println()
+    fmt.Println("search options:")
+    fmt.Println("  -scan        Also list every XML file that mentions the EIN anywhere (slow, reads all files)")
+    fmt.Println()
     fmt.Println("Example workflow:")
     fmt.Println("  ./theIRS sync    # Download missing files")
     fmt.Println("  ./theIRS unzip   # Extract ZIP archives")
     fmt.Println("  ./theIRS csv     # Generate CSV from XML files")
+    fmt.Println("  ./theIRS search 123456789")
 }
 
 func main() {
     if len(os.Args) < 2 {
         printUsage()
         return
-    } else if len(os.Args) > 2 {
+    } else if len(os.Args) > 2 && os.Args[1] != "csv" && os.Args[1] != "search" {
         fmt.Println("Error: Too many arguments")
         printUsage()
         return
@@ -102,9 +114,12 @@ func main() {
             if err := CheckAndDownloadMissingZips(); err != nil {
                 fmt.Printf("Error: %v\n", err)
                 os.Exit(1)
-            } else {
-                fmt.Println("Sync complete!")
             }
+            if err := SyncReferenceData(); err != nil {
+                fmt.Printf("Error: %v\n", err)
+                os.Exit(1)
+            }
+            fmt.Println("Sync complete!")
         } else {
             fmt.Println("Aborting")
         }
@@ -160,6 +175,12 @@ func main() {
         }
 
     case "csv":
+        var options csvOptions
+        flags := flag.NewFlagSet("csv", flag.ExitOnError)
+        flags.BoolVar(&options.provenance, "provenance", false, "write the source of every value to irs_990_data_provenance.csv")
+        flags.BoolVar(&options.latestOnly, "latest", false, "drop superseded versions of amended or duplicate filings")
+        flags.Parse(os.Args[2:])
+
         proceed, err := confirmation(`
         This will process all XML files in the ./data/990_zips directories
         and create a comprehensive CSV file with IRS Form 990 data.
@@ -172,7 +193,7 @@ func main() {
             os.Exit(1)
         }
         if proceed {
-            if err := ProcessAllDirectories(); err != nil {
+            if err := ProcessAllDirectories(options); err != nil {
                 fmt.Printf("Error: %v\n", err)
                 os.Exit(1)
             } else {
@@ -182,6 +203,49 @@ func main() {
             fmt.Println("Aborting")
         }
 
+    case "eav":
+        proceed, err := confirmation(`
+        This will export every element and attribute of all XML files in the
+        ./data/990_zips directories as (ObjectID, EIN, TaxYear, ElementPath, Value) rows.
+
+        Output file: irs_990_data_eav.csv
+
+        `, 3)
+        if err != nil {
+            fmt.Printf("Error reading confirmation: %v\n", err)
+            os.Exit(1)
+        }
+        if proceed {
+            if err := ExportAllDirectories(); err != nil {
+                fmt.Printf("Error: %v\n", err)
+                os.Exit(1)
+            } else {
+                fmt.Println("Export complete! Check irs_990_data_eav.csv")
+            }
+        } else {
+            fmt.Println("Aborting")
+        }
+
+    case "discover":
+        if err := DiscoverAllDirectories(); err != nil {
+            fmt.Printf("Error: %v\n", err)
+            os.Exit(1)
+        }
+        fmt.Println("Discovery complete! Check irs_990_paths.csv")
+
+    case "search":
+        flags := flag.NewFlagSet("search", flag.ExitOnError)
+        scan := flags.Bool("scan", false, "list every XML file that mentions the EIN")
+        flags.Parse(os.Args[2:])
+        if flags.NArg() != 1 {
+            fmt.Println("Usage: theIRS search [-scan] <EIN>")
+            os.Exit(1)
+        }
+        if err := SearchEIN(flags.Arg(0), *scan); err != nil {
+            fmt.Printf("Error: %v\n", err)
+            os.Exit(1)
+        }
+
     default:
         fmt.Printf("Error: Unknown command '%s'\n\n", os.Args[1])
         printUsage()
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Free-text extraction. Every leaf under ReturnData whose element name ends in
+// Txt or Desc becomes a narrative row, except names and addresses. Explanations
+// that sit next to a FormAndLineReferenceDesc (Schedule O and the supplemental
+// parts of other schedules) carry that reference.
+
+var narrativeTable = tableSpec{
+	name: "narrative",
+	columns: []string{
+		"Document",
+		"ElementPath",
+		"LineReference",
+		"ReferencedPart",
+		"ReferencedLine",
+		"Text",
+	},
+}
+
+// narrativeExcluded are Txt/Desc elements that hold names, addresses or codes
+var narrativeExcluded = map[string]bool{
+	"BusinessNameLine1Txt":       true,
+	"BusinessNameLine2Txt":       true,
+	"AddressLine1Txt":            true,
+	"AddressLine2Txt":            true,
+	"TitleTxt":                   true,
+	"PersonTitleTxt":             true,
+	"WebsiteAddressTxt":          true,
+	"FormAndLineReferenceDesc":   true,
+	"ExemptCodeSectionTxt":       true,
+	"PublicCharityStatusTxt":     true,
+	"TransactionTypeTxt":         true,
+	"RelationshipDescriptionTxt": true,
+	"RelationshipWithOrgTxt":     true,
+}
+
+// narrativeLineReferences gives the form line of text elements that are not
+// accompanied by a FormAndLineReferenceDesc
+var narrativeLineReferences = map[string]string{
+	form990Path + "ActivityOrMissionDesc":                "Form 990, Part I, Line 1",
+	form990Path + "MissionDesc":                          "Form 990, Part III, Line 1",
+	form990Path + "ProgSrvcAccomActyGrp.Desc":            "Form 990, Part III, Line 4a",
+	form990Path + "ProgSrvcAccomActy2Grp.Desc":           "Form 990, Part III, Line 4b",
+	form990Path + "ProgSrvcAccomActy3Grp.Desc":           "Form 990, Part III, Line 4c",
+	form990Path + "ProgSrvcAccomActyOtherGrp.Desc":       "Form 990, Part III, Line 4d",
+	"Return.ReturnData.IRS990EZ.PrimaryExemptPurposeTxt": "Form 990-EZ, Part III",
+}
+
+// lineReferencePattern pulls the part and line out of references such as
+// "FORM 990, PART VI, LINE 11B" or "Schedule A, Part II, line 10"
+var lineReferencePattern = regexp.MustCompile(`(?i)\bpart\s+([IVX]+)(?:.*?\blines?\s+([0-9]+[a-z]?))?`)
+
+// narrativeCollector turns text leaves into narrative rows
+type narrativeCollector struct {
+	reference string // FormAndLineReferenceDesc of the innermost open group
+	refDepth  int    // path stack length of the group holding reference
+}
+
+// leaf records a text leaf, or remembers a line reference for its siblings
+func (n *narrativeCollector) leaf(pathStack []string, fullPath, value string) map[string]string {
+	if len(pathStack) < 3 || pathStack[1] != "ReturnData" {
+		return nil
+	}
+
+	name := pathStack[len(pathStack)-1]
+	if name == "FormAndLineReferenceDesc" {
+		n.reference = value
+		n.refDepth = len(pathStack) - 1
+		return nil
+	}
+	if narrativeExcluded[name] || !(strings.HasSuffix(name, "Txt") || strings.HasSuffix(name, "Desc")) {
+		return nil
+	}
+
+	reference := narrativeLineReferences[fullPath]
+	if n.reference != "" && n.refDepth == len(pathStack)-1 {
+		reference = n.reference
+	}
+
+	row := map[string]string{
+		"Document":      pathStack[2],
+		"ElementPath":   fullPath,
+		"LineReference": reference,
+		"Text":          value,
+	}
+	if match := lineReferencePattern.FindStringSubmatch(reference); match != nil {
+		row["ReferencedPart"] = strings.ToUpper(match[1])
+		row["ReferencedLine"] = strings.ToLower(match[2])
+	}
+	return row
+}
+
+// end forgets the line reference when the group holding it closes
+func (n *narrativeCollector) end(pathStack []string) {
+	if n.reference != "" && len(pathStack) == n.refDepth {
+		n.reference = ""
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value normalization. Leaf values are typed by the suffix of their element
+// name, following the e-file naming convention:
+//
+//	...Amt        amount, written as a whole number (negatives kept)
+//	...Ind        indicator (X, 1, true, 0, false), written as true/false
+//	...Dt         date, written as YYYY-MM-DD
+//	...Ts         timestamp, written as ISO-8601 in UTC
+//	...CountryCd  IRS country code, written as ISO 3166-1 alpha-2
+//
+// Other values pass through unchanged. Values that do not parse are kept out
+// of the outputs and reported in the parse_errors table instead.
+
+var parseErrorsTable = tableSpec{
+	name: "parse_errors",
+	columns: []string{
+		"ElementPath",
+		"Value",
+		"Error",
+	},
+}
+
+// dateLayouts are the accepted forms of xs:date, with or without a zone
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02Z07:00",
+}
+
+// timestampLayouts are the accepted forms of xs:dateTime, values without a
+// zone are taken as UTC
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// normalizeValue returns the canonical form of the value of element name
+func normalizeValue(name, value string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, "Amt"):
+		return normalizeAmount(value)
+	case strings.HasSuffix(name, "Ind"):
+		return normalizeIndicator(value)
+	case strings.HasSuffix(name, "Dt"):
+		return normalizeDate(value)
+	case strings.HasSuffix(name, "Ts"):
+		return normalizeTimestamp(value)
+	case strings.HasSuffix(name, "CountryCd"):
+		return normalizeCountry(value)
+	}
+	return value, nil
+}
+
+// normalizeAmount accepts whole amounts and rounds the few decimal amount types
+func normalizeAmount(value string) (string, error) {
+	if amount, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return strconv.FormatInt(amount, 10), nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return "", fmt.Errorf("invalid amount")
+	}
+	return strconv.FormatInt(int64(math.Round(amount)), 10), nil
+}
+
+func normalizeIndicator(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "x", "1", "true", "yes":
+		return "true", nil
+	case "0", "false", "no":
+		return "false", nil
+	}
+	return "", fmt.Errorf("invalid indicator")
+}
+
+func normalizeCountry(value string) (string, error) {
+	if code, ok := isoCountryCodes[strings.ToUpper(value)]; ok {
+		return code, nil
+	}
+	return "", fmt.Errorf("no ISO country code")
+}
+
+func normalizeDate(value string) (string, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date")
+}
+
+func normalizeTimestamp(value string) (string, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	}
+	return "", fmt.Errorf("invalid timestamp")
+}
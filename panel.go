@@ -0,0 +1,194 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Organization panel. One row per EIN and tax year from the authoritative
+// filings, in EIN and year order. When an organization files more than one
+// authoritative return for a tax year (a short year after a change of fiscal
+// year) the one with the latest period end is used.
+//
+// Changes and growth rates compare with the previous tax year and are left
+// empty after a gap year, when GapBefore is true and GapYears counts the tax
+// years missing since the previous filing. Growth is the change over the
+// absolute prior value, empty when the prior value is zero. Every row also
+// carries the financial ratios of its filing (see ratios.go) and the reference
+// data of the EIN at its tax period end (see reference.go).
+
+// panelMetrics are the main record columns followed year over year
+var panelMetrics = []string{"TotalRevenue", "TotalExpenses", "TotalAssets", "NetAssets"}
+
+var panelTable = tableSpec{
+	name:    "panel",
+	columns: panelColumns(),
+}
+
+// panelColumns adds the value, change and growth columns of every metric
+func panelColumns() []string {
+	columns := []string{
+		"OrganizationName",
+		"ReturnType",
+		"TaxPeriodEnd",
+		"FilingsInYear",
+		"PriorTaxYear",
+		"GapBefore",
+		"GapYears",
+		"PriorReturnType",
+		"ReturnTypeChanged",
+		"ReturnTypeHistory",
+	}
+	for _, metric := range panelMetrics {
+		columns = append(columns, metric, metric+"Change", metric+"Growth")
+	}
+	columns = append(columns, ratioColumns...)
+	return append(columns, referenceColumns...)
+}
+
+// panelFiling is the part of a main record the panel needs
+type panelFiling struct {
+	name       string
+	returnType string
+	values     []string // panelMetrics order
+	inputs     []string // ratioInputs order
+}
+
+func (p *XMLToCSVProcessor) panelFilingOf(record []string) panelFiling {
+	filing := panelFiling{
+		name:       record[p.fieldMap["OrganizationName"]],
+		returnType: record[p.fieldMap["ReturnType"]],
+		values:     make([]string, len(panelMetrics)),
+		inputs:     make([]string, len(ratioInputs)),
+	}
+	for i, metric := range panelMetrics {
+		filing.values[i] = record[p.fieldMap[metric]]
+	}
+	for i, col := range ratioInputs {
+		filing.inputs[i] = record[p.fieldMap[col]]
+	}
+	return filing
+}
+
+// writePanel writes the panel of the filings that are not superseded
+func writePanel(outputPath string, versions []filingVersion, superseded map[string]bool, references []referenceSet) error {
+	// Latest period end per EIN and tax year
+	years := make(map[[2]string]filingVersion)
+	counts := make(map[[2]string]int)
+	for _, v := range versions {
+		year, err := strconv.Atoi(v.taxYear)
+		if superseded[v.fileName] || v.ein == "" || err != nil || year == 0 {
+			continue
+		}
+		key := [2]string{v.ein, v.taxYear}
+		counts[key]++
+		if current, ok := years[key]; !ok || v.taxPeriodEnd > current.taxPeriodEnd {
+			years[key] = v
+		}
+	}
+
+	keys := make([][2]string, 0, len(years))
+	for key := range years {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	table, err := newTableWriter(outputPath, &panelTable)
+	if err != nil {
+		return err
+	}
+	defer table.Close()
+
+	// Rows of one EIN are consecutive; history collects its return types
+	var organization []filingVersion
+	flush := func() error {
+		history := make([]string, len(organization))
+		for i, v := range organization {
+			history[i] = v.taxYear + ":" + v.panel.returnType
+		}
+
+		for i, v := range organization {
+			row := map[string]string{
+				"OrganizationName":  v.panel.name,
+				"ReturnType":        v.panel.returnType,
+				"TaxPeriodEnd":      v.taxPeriodEnd,
+				"FilingsInYear":     strconv.Itoa(counts[[2]string{v.ein, v.taxYear}]),
+				"GapBefore":         "false",
+				"GapYears":          "0",
+				"ReturnTypeChanged": "false",
+				"ReturnTypeHistory": strings.Join(history, ";"),
+			}
+			for j, metric := range panelMetrics {
+				row[metric] = v.panel.values[j]
+			}
+			inputs := make(map[string]string, len(ratioInputs))
+			for j, col := range ratioInputs {
+				inputs[col] = v.panel.inputs[j]
+			}
+			for col, ratio := range computeRatios(inputs) {
+				row[col] = ratio
+			}
+			fillReferenceColumns(references, v.ein, v.taxPeriodEnd, func(col, value string) {
+				row[col] = value
+			})
+
+			if i > 0 {
+				prior := organization[i-1]
+				year, _ := strconv.Atoi(v.taxYear)
+				priorYear, _ := strconv.Atoi(prior.taxYear)
+				row["PriorTaxYear"] = prior.taxYear
+				row["PriorReturnType"] = prior.panel.returnType
+				row["ReturnTypeChanged"] = strconv.FormatBool(prior.panel.returnType != v.panel.returnType)
+				row["GapYears"] = strconv.Itoa(year - priorYear - 1)
+				row["GapBefore"] = strconv.FormatBool(year-priorYear > 1)
+
+				if year-priorYear == 1 {
+					for j, metric := range panelMetrics {
+						current, okC := parseAmount(v.panel.values[j])
+						previous, okP := parseAmount(prior.panel.values[j])
+						if !okC || !okP {
+							continue
+						}
+						row[metric+"Change"] = strconv.FormatInt(current-previous, 10)
+						row[metric+"Growth"] = formatShare(current-previous, abs(previous))
+					}
+				}
+			}
+
+			if err := table.writeRows([]map[string]string{row}, nil, []string{v.fileName, v.ein, v.taxYear}); err != nil {
+				return err
+			}
+		}
+		organization = organization[:0]
+		return nil
+	}
+
+	for _, key := range keys {
+		if len(organization) > 0 && organization[0].ein != key[0] {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		organization = append(organization, years[key])
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Panel: %d organization years", len(keys))
+	return nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,66 @@
+package main
+
+// Schedule C political campaign and lobbying activities, one row per filing.
+// Part I covers political expenditures, Part II-A lobbying by organizations
+// that made the 501(h) election and Part II-B lobbying by all others.
+
+var scheduleCTable = tableSpec{
+	name: "schedule_c",
+	columns: []string{
+		"PoliticalExpenditures",
+		"VolunteerHours",
+		"Section4955OrganizationTax",
+		"Section4955ManagersTax",
+		"Form4720Filed",
+		"CorrectionMade",
+		"ExemptFunctionExpenditures",
+		"InternalFundsContributed",
+		"TotalExemptFunctionExpenditures",
+		"Form1120POLFiled",
+		"Section501hElection",
+		"GrassrootsLobbying",
+		"DirectLobbying",
+		"TotalLobbying",
+		"OtherExemptPurposeExpenditures",
+		"TotalExemptPurposeExpenditures",
+		"LobbyingNontaxableAmount",
+		"GrassrootsNontaxableAmount",
+		"NonElectingTotalLobbying",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnData.IRS990ScheduleC"},
+	},
+	fields: map[string]string{
+		"PoliticalExpendituresAmt":                                  "PoliticalExpenditures",
+		"VolunteerHoursCnt":                                         "VolunteerHours",
+		"Section4955OrganizationTaxAmt":                             "Section4955OrganizationTax",
+		"Section4955ManagersTaxAmt":                                 "Section4955ManagersTax",
+		"Form4720FiledInd":                                          "Form4720Filed",
+		"CorrectionMadeInd":                                         "CorrectionMade",
+		"ExemptFunctionActyExpendAmt":                               "ExemptFunctionExpenditures",
+		"InternalFundsContributedAmt":                               "InternalFundsContributed",
+		"TotalExemptFunctionExpendAmt":                              "TotalExemptFunctionExpenditures",
+		"Form1120POLFiledInd":                                       "Form1120POLFiled",
+		"TotalGrassrootsLobbyingGrp.FilingOrganizationsTotalAmt":    "GrassrootsLobbying",
+		"TotalDirectLobbyingGrp.FilingOrganizationsTotalAmt":        "DirectLobbying",
+		"TotalLobbyingExpendGrp.FilingOrganizationsTotalAmt":        "TotalLobbying",
+		"OtherExemptPurposeExpendGrp.FilingOrganizationsTotalAmt":   "OtherExemptPurposeExpenditures",
+		"TotalExemptPurposeExpendGrp.FilingOrganizationsTotalAmt":   "TotalExemptPurposeExpenditures",
+		"LobbyingNontaxableAmountGrp.FilingOrganizationsTotalAmt":   "LobbyingNontaxableAmount",
+		"GrassrootsNontaxableAmountGrp.FilingOrganizationsTotalAmt": "GrassrootsNontaxableAmount",
+		"TotalLobbyingExpendituresAmt":                              "NonElectingTotalLobbying",
+	},
+	derive: deriveSection501hElection,
+}
+
+// deriveSection501hElection marks filings that completed Part II-A, which only
+// organizations that elected under section 501(h) fill in
+func deriveSection501hElection(row map[string]string) {
+	for _, col := range []string{"GrassrootsLobbying", "DirectLobbying", "TotalLobbying", "LobbyingNontaxableAmount"} {
+		if row[col] != "" {
+			row["Section501hElection"] = "true"
+			return
+		}
+	}
+	row["Section501hElection"] = "false"
+}
@@ -0,0 +1,75 @@
+package main
+
+import "strconv"
+
+// Part III program service accomplishments: the three largest programs
+// (lines 4a-4c) and all other programs (line 4d), one row per program.
+
+var programsTable = tableSpec{
+	name: "programs",
+	columns: []string{
+		"Line",
+		"LineId",
+		"ActivityCode",
+		"Description",
+		"Expense",
+		"Grants",
+		"Revenue",
+		"TotalProgramExpenses",
+		"ShareOfProgramExpenses",
+		"ShareOfTotalExpenses",
+	},
+	groups: lineGroups(form990Path, []formLine{
+		{"4a", "program_1", "ProgSrvcAccomActyGrp"},
+		{"4b", "program_2", "ProgSrvcAccomActy2Grp"},
+		{"4c", "program_3", "ProgSrvcAccomActy3Grp"},
+		{"4d", "other_programs", "ProgSrvcAccomActyOtherGrp"},
+	}),
+	fields: map[string]string{
+		"ActivityCd": "ActivityCode",
+		"Desc":       "Description",
+		"ExpenseAmt": "Expense",
+		"GrantAmt":   "Grants",
+		"RevenueAmt": "Revenue",
+	},
+	shared: map[string]string{
+		form990Path + "TotalProgramServiceExpensesAmt": "TotalProgramExpenses",
+	},
+}
+
+// summarizePrograms sets each program's share of program service expenses
+// (line 4e, or the sum of the rows when 4e is missing) and of total expenses
+func (p *XMLToCSVProcessor) summarizePrograms(tables *tableTracker, record []string) {
+	rows := tables.rows[programsTable.name]
+	if len(rows) == 0 {
+		return
+	}
+
+	programTotal, ok := parseAmount(tables.shared[programsTable.name]["TotalProgramExpenses"])
+	if !ok {
+		programTotal = 0
+		for _, row := range rows {
+			if amount, ok := parseAmount(row["Expense"]); ok {
+				programTotal += amount
+			}
+		}
+	}
+	total, _ := parseAmount(record[p.fieldMap["TotalExpenses"]])
+
+	for _, row := range rows {
+		expense, ok := parseAmount(row["Expense"])
+		if !ok {
+			continue
+		}
+		row["ShareOfProgramExpenses"] = formatShare(expense, programTotal)
+		row["ShareOfTotalExpenses"] = formatShare(expense, total)
+	}
+}
+
+// formatShare renders part/whole with four decimals, or "" when whole is zero
+func formatShare(part, whole int64) string {
+	if whole == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(part)/float64(whole), 'f', 4, 64)
+}
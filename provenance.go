@@ -0,0 +1,35 @@
+package main
+
+// Provenance of the main CSV. With csv -provenance every non-empty column of
+// the main record gets a row naming the element path it was read from and
+// whether an exact mapping or a name heuristic matched it. Values computed
+// from the side tables are derived and have no single source path.
+
+var provenanceTable = tableSpec{
+	name: "provenance",
+	columns: []string{
+		"Column",
+		"Value",
+		"SourcePath",
+		"Method",
+	},
+}
+
+// provenanceRows lists the source of every non-empty column of record except
+// the file name, which is a key column of the table
+func (p *XMLToCSVProcessor) provenanceRows(record []string, sources []fieldSource) []map[string]string {
+	var rows []map[string]string
+	for i, col := range p.header {
+		if record[i] == "" || col == "FileName" {
+			continue
+		}
+		source := sourceOf(record, sources, i)
+		rows = append(rows, map[string]string{
+			"Column":     col,
+			"Value":      record[i],
+			"SourcePath": source.path,
+			"Method":     source.method,
+		})
+	}
+	return rows
+}
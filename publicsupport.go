@@ -0,0 +1,193 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// Schedule A public charity status: the Part II (170(b)(1)(A)(vi)) and
+// Part III (509(a)(2)) five-year support schedules, one row per line, and a
+// status row per filing with the Part I reason checkboxes, the reported
+// support percentages and the percentages recomputed from the schedules.
+
+const scheduleAPath = "Return.ReturnData.IRS990ScheduleA."
+
+// supportPctTolerance absorbs the rounding of reported percentages
+const supportPctTolerance = 0.001
+
+var scheduleASupportTable = tableSpec{
+	name: "schedule_a_support",
+	columns: []string{
+		"Part",
+		"Line",
+		"LineId",
+		"YearMinus4",
+		"YearMinus3",
+		"YearMinus2",
+		"YearMinus1",
+		"CurrentYear",
+		"Total",
+	},
+	groups: append(
+		partGroups("II", lineGroups(scheduleAPath, []formLine{
+			{"1", "gifts_grants_contributions", "GiftsGrantsContriRcvd170Grp"},
+			{"2", "tax_revenues_levied", "TaxRevLevied170Grp"},
+			{"3", "government_services_facilities", "GovtFurnSrvcFcltsVl170Grp"},
+			{"4", "total_lines_1_3", "TotalCalendarYear170Grp"},
+			{"5", "substantial_contributor_excess", "SubstantialContributorsTotAmt"},
+			{"6", "public_support", "PublicSupportTotal170Amt"},
+			{"8", "gross_investment_income", "GrossInvestmentIncome170Grp"},
+			{"9", "net_unrelated_business_income", "UnrelatedBusinessNetIncm170Grp"},
+			{"10", "other_income", "OtherIncome170Grp"},
+			{"11", "total_support", "TotalSupportAmt"},
+			{"12", "gross_receipts_related_activities", "GrossReceiptsRltdActivitiesAmt"},
+		})),
+		partGroups("III", lineGroups(scheduleAPath, []formLine{
+			{"1", "gifts_grants_contributions", "GiftsGrantsContrisRcvd509Grp"},
+			{"2", "admissions_merchandise_services", "GrossReceiptsAdmissionsGrp"},
+			{"3", "gross_receipts_nonunrelated_business", "GrossReceiptsNonUnrltTrdBusGrp"},
+			{"4", "tax_revenues_levied", "TaxRevLevied509Grp"},
+			{"5", "government_services_facilities", "GovtFurnSrvcFcltsVl509Grp"},
+			{"6", "total_lines_1_5", "Total509Grp"},
+			{"7a", "disqualified_person_amounts", "AmountsRcvdDsqlfyPersonGrp"},
+			{"7b", "other_person_excess_amounts", "SubstContriAmtsRcvdGrp"},
+			{"7c", "total_lines_7a_7b", "TotalAmountsRcvdGrp"},
+			{"8", "public_support", "PublicSupportTotal509Grp"},
+			{"9", "amounts_from_line_6", "AmountsFromLine6Grp"},
+			{"10a", "gross_investment_income", "GrossInvestmentIncome509Grp"},
+			{"10b", "unrelated_business_taxable_income", "UBTIAfter1975Grp"},
+			{"10c", "investment_income_and_ubti", "InvestmentIncomeAndUBTIGrp"},
+			{"11", "net_unrelated_business_income", "NetIncomeFromOtherUBIGrp"},
+			{"12", "other_income", "OtherIncome509Grp"},
+			{"13", "total_support", "TotalSupport509Grp"},
+		}))...,
+	),
+	fields: map[string]string{
+		"":                             "Total", // single amount lines
+		"CurrentTaxYearMinus4YearsAmt": "YearMinus4",
+		"CurrentTaxYearMinus3YearsAmt": "YearMinus3",
+		"CurrentTaxYearMinus2YearsAmt": "YearMinus2",
+		"CurrentTaxYearMinus1YearAmt":  "YearMinus1",
+		"CurrentTaxYearAmt":            "CurrentYear",
+		"TotalAmt":                     "Total",
+	},
+}
+
+// scheduleAStatusTable has one row per Schedule A, the group is the schedule itself
+var scheduleAStatusTable = tableSpec{
+	name: "schedule_a_status",
+	columns: []string{
+		// Part I reason for public charity status
+		"Church",
+		"School",
+		"Hospital",
+		"MedicalResearch",
+		"CollegeSupport",
+		"GovernmentalUnit",
+		"PublicSupport170",
+		"CommunityTrust",
+		"AgriculturalResearch",
+		"PublicSupport509a2",
+		"PublicSafety",
+		"SupportingOrganization",
+		// Reported results
+		"PublicSupportPct",
+		"PublicSupportPriorYearPct",
+		"InvestmentIncomePct",
+		"ThirtyThreePctTest",
+		"TenPctFactsCircumstancesTest",
+		"PrivateFoundation",
+		// Recomputed from the support schedule
+		"RecomputedPublicSupportPct",
+		"RecomputedInvestmentIncomePct",
+		"RecomputedThirtyThreePctTest",
+		"RecomputedTenPctTest",
+		"SupportPctMismatch",
+	},
+	groups: []tableGroup{
+		{path: "Return.ReturnData.IRS990ScheduleA"},
+	},
+	fields: map[string]string{
+		"ChurchInd":                      "Church",
+		"SchoolInd":                      "School",
+		"HospitalInd":                    "Hospital",
+		"MedicalResearchOrganizationInd": "MedicalResearch",
+		"CollegeOrganizationInd":         "CollegeSupport",
+		"GovernmentalUnitInd":            "GovernmentalUnit",
+		"PublicOrganization170Ind":       "PublicSupport170",
+		"CommunityTrustInd":              "CommunityTrust",
+		"AgriculturalResearchOrgInd":     "AgriculturalResearch",
+		"PublicOrganization509a2Ind":     "PublicSupport509a2",
+		"PublicSafetyInd":                "PublicSafety",
+		"SupportingOrganization509a3Ind": "SupportingOrganization",
+		"PublicSupportCY170Pct":          "PublicSupportPct",
+		"PublicSupportPY170Pct":          "PublicSupportPriorYearPct",
+		"ThirtyThrPctSuprtTestsCY170Ind": "ThirtyThreePctTest",
+		"TenPctFactsCrcmstncsTestCYInd":  "TenPctFactsCircumstancesTest",
+		"PrivateFoundation170Ind":        "PrivateFoundation",
+		"PublicSupportCY509Pct":          "PublicSupportPct",
+		"PublicSupportPY509Pct":          "PublicSupportPriorYearPct",
+		"InvestmentIncomeCY509Pct":       "InvestmentIncomePct",
+		"ThirtyThrPctSuprtTestsCY509Ind": "ThirtyThreePctTest",
+		"PrivateFoundation509Ind":        "PrivateFoundation",
+	},
+}
+
+// summarizePublicSupport recomputes the public support percentage of the
+// filing from its support schedule and flags disagreement with the reported one.
+//
+// Part II: line 6 (line 4 total less line 5) over line 11 (line 4 plus
+// lines 8-10), with the 33 1/3% and 10% facts-and-circumstances thresholds.
+// Part III: line 8 over line 13, passing at 33 1/3% with investment income
+// (line 10c, lines 10a plus 10b) over line 13 at most 33 1/3%.
+func (p *XMLToCSVProcessor) summarizePublicSupport(tables *tableTracker) {
+	statusRows := tables.rows[scheduleAStatusTable.name]
+	if len(statusRows) == 0 {
+		return
+	}
+	status := statusRows[0]
+
+	lines := make(map[string]int64)
+	found := make(map[string]bool)
+	for _, row := range tables.rows[scheduleASupportTable.name] {
+		if amount, ok := parseAmount(row["Total"]); ok {
+			key := row["Part"] + ":" + row["Line"]
+			lines[key] = amount
+			found[key] = true
+		}
+	}
+
+	var public, total, investment int64
+	switch {
+	case found["II:4"]:
+		public = lines["II:4"] - lines["II:5"]
+		total = lines["II:4"] + lines["II:8"] + lines["II:9"] + lines["II:10"]
+	case found["III:8"] && found["III:13"]:
+		public = lines["III:8"]
+		total = lines["III:13"]
+		investment = lines["III:10a"] + lines["III:10b"]
+		if found["III:10c"] {
+			investment = lines["III:10c"]
+		}
+	default:
+		return
+	}
+	if total == 0 {
+		return
+	}
+
+	pct := float64(public) / float64(total)
+	status["RecomputedPublicSupportPct"] = strconv.FormatFloat(pct, 'f', 4, 64)
+	status["RecomputedThirtyThreePctTest"] = strconv.FormatBool(pct >= 1.0/3.0)
+	if found["II:4"] {
+		status["RecomputedTenPctTest"] = strconv.FormatBool(pct >= 0.10 && pct < 1.0/3.0)
+	} else {
+		investmentPct := float64(investment) / float64(total)
+		status["RecomputedInvestmentIncomePct"] = strconv.FormatFloat(investmentPct, 'f', 4, 64)
+		status["RecomputedThirtyThreePctTest"] = strconv.FormatBool(pct > 1.0/3.0 && investmentPct <= 1.0/3.0)
+	}
+
+	if reported, err := strconv.ParseFloat(status["PublicSupportPct"], 64); err == nil {
+		status["SupportPctMismatch"] = strconv.FormatBool(math.Abs(reported-pct) > supportPctTolerance)
+	}
+}
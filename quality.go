@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Data quality report of a csv run: fill rates per column by return type and
+// tax year, how values were matched, parse failures, numeric outliers and
+// filings without their core identifiers. Written as JSON and as a text summary.
+//
+// Outliers are found on a signed log scale, sign(v) * log10(1+|v|), bucketed
+// in quarter decades. Values more than three interquartile ranges (at least
+// three decades) outside the quartiles of their column are outliers.
+
+const (
+	outlierBucketsPerDecade = 4
+	outlierExamples         = 5
+	missingIDExamples       = 100
+)
+
+// identifierColumns must be present in every filing
+var identifierColumns = []string{"EIN", "TaxYear", "ReturnType"}
+
+// nonNumericColumns hold digits but are codes, not quantities
+var nonNumericColumns = map[string]bool{
+	"FileName":        true,
+	"EIN":             true,
+	"TaxYear":         true,
+	"ZIPCode":         true,
+	"Phone":           true,
+	"PreparerPhone":   true,
+	"SoftwareID":      true,
+	"SoftwareVersion": true,
+	"FormVersion":     true,
+}
+
+// qualityReport accumulates the quality statistics of a run
+type qualityReport struct {
+	header      []string
+	filings     int
+	groups      map[[2]string]*qualityGroup // return type and tax year
+	methods     []map[string]int            // per column: match method -> values
+	numeric     []*numericStats             // per column, nil for non-numeric columns
+	parseErrors map[string]int              // element path -> failures
+	missing     map[string]int              // identifier column -> filings without it
+	missingAny  int
+	missingIn   []string // file names of the first filings with a missing identifier
+}
+
+type qualityGroup struct {
+	filings int
+	filled  []int
+}
+
+// numericStats is the log-scale histogram and the extreme values of a column
+type numericStats struct {
+	values  int
+	buckets map[int]int
+	lowest  []outlierValue
+	highest []outlierValue
+}
+
+type outlierValue struct {
+	FileName string `json:"fileName"`
+	Value    int64  `json:"value"`
+}
+
+func newQualityReport(header []string) *qualityReport {
+	q := &qualityReport{
+		header:      header,
+		groups:      make(map[[2]string]*qualityGroup),
+		methods:     make([]map[string]int, len(header)),
+		numeric:     make([]*numericStats, len(header)),
+		parseErrors: make(map[string]int),
+		missing:     make(map[string]int),
+		missingIn:   []string{},
+	}
+	for i, col := range header {
+		q.methods[i] = make(map[string]int)
+		if !nonNumericColumns[col] {
+			q.numeric[i] = &numericStats{buckets: make(map[int]int)}
+		}
+	}
+	return q
+}
+
+// add counts one filing
+func (q *qualityReport) add(record []string, sources []fieldSource, fieldMap map[string]int, tables *tableTracker) {
+	q.filings++
+	key := [2]string{record[fieldMap["ReturnType"]], record[fieldMap["TaxYear"]]}
+	group, ok := q.groups[key]
+	if !ok {
+		group = &qualityGroup{filled: make([]int, len(q.header))}
+		q.groups[key] = group
+	}
+	group.filings++
+
+	fileName := record[fieldMap["FileName"]]
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		group.filled[i]++
+		q.methods[i][sourceOf(record, sources, i).method]++
+		if q.numeric[i] != nil {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				q.numeric[i].add(fileName, n)
+			}
+		}
+	}
+
+	for _, row := range tables.rows[parseErrorsTable.name] {
+		q.parseErrors[row["ElementPath"]]++
+	}
+
+	missingAny := false
+	for _, col := range identifierColumns {
+		if record[fieldMap[col]] == "" {
+			q.missing[col]++
+			missingAny = true
+		}
+	}
+	if missingAny {
+		q.missingAny++
+		if len(q.missingIn) < missingIDExamples {
+			q.missingIn = append(q.missingIn, fileName)
+		}
+	}
+}
+
+// logScale maps a value to sign(v) * log10(1+|v|)
+func logScale(v int64) float64 {
+	scaled := math.Log10(1 + math.Abs(float64(v)))
+	if v < 0 {
+		return -scaled
+	}
+	return scaled
+}
+
+func (s *numericStats) add(fileName string, v int64) {
+	s.values++
+	s.buckets[int(math.Round(logScale(v)*outlierBucketsPerDecade))]++
+
+	value := outlierValue{FileName: fileName, Value: v}
+	s.highest = keepExtremes(s.highest, value, func(a, b int64) bool { return a > b })
+	s.lowest = keepExtremes(s.lowest, value, func(a, b int64) bool { return a < b })
+}
+
+// keepExtremes inserts value into the sorted list of the most extreme values
+func keepExtremes(list []outlierValue, value outlierValue, more func(a, b int64) bool) []outlierValue {
+	if len(list) == outlierExamples && !more(value.Value, list[len(list)-1].Value) {
+		return list
+	}
+	i := sort.Search(len(list), func(i int) bool { return more(value.Value, list[i].Value) })
+	list = append(list, outlierValue{})
+	copy(list[i+1:], list[i:])
+	list[i] = value
+	if len(list) > outlierExamples {
+		list = list[:outlierExamples]
+	}
+	return list
+}
+
+// quantile returns the log-scale value below which a fraction q of the values fall
+func (s *numericStats) quantile(q float64) float64 {
+	keys := make([]int, 0, len(s.buckets))
+	for k := range s.buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	target := q * float64(s.values)
+	seen := 0
+	for _, k := range keys {
+		seen += s.buckets[k]
+		if float64(seen) >= target {
+			return float64(k) / outlierBucketsPerDecade
+		}
+	}
+	return 0
+}
+
+// qualityOutput is the JSON form of the report
+type qualityOutput struct {
+	Filings            int                       `json:"filings"`
+	MissingIdentifiers missingIdentifiersOutput  `json:"missingIdentifiers"`
+	ParseErrors        parseErrorsOutput         `json:"parseErrors"`
+	MatchMethods       map[string]map[string]int `json:"matchMethods"`
+	FillRates          []fillRateOutput          `json:"fillRates"`
+	Outliers           map[string]outliersOutput `json:"outliers"`
+}
+
+type missingIdentifiersOutput struct {
+	Filings  int            `json:"filings"`
+	ByColumn map[string]int `json:"byColumn"`
+	Examples []string       `json:"examples"`
+}
+
+type parseErrorsOutput struct {
+	Total         int            `json:"total"`
+	ByElementPath map[string]int `json:"byElementPath"`
+}
+
+type fillRateOutput struct {
+	ReturnType string             `json:"returnType"`
+	TaxYear    string             `json:"taxYear"`
+	Filings    int                `json:"filings"`
+	Columns    map[string]float64 `json:"columns"`
+}
+
+type outliersOutput struct {
+	Values     int            `json:"values"`
+	LowerFence int64          `json:"lowerFence"`
+	UpperFence int64          `json:"upperFence"`
+	Outliers   int            `json:"outliers"`
+	Examples   []outlierValue `json:"examples"`
+}
+
+// output builds the JSON form of the report
+func (q *qualityReport) output() qualityOutput {
+	out := qualityOutput{
+		Filings: q.filings,
+		MissingIdentifiers: missingIdentifiersOutput{
+			Filings:  q.missingAny,
+			ByColumn: q.missing,
+			Examples: q.missingIn,
+		},
+		ParseErrors: parseErrorsOutput{
+			ByElementPath: q.parseErrors,
+		},
+		MatchMethods: make(map[string]map[string]int),
+		Outliers:     make(map[string]outliersOutput),
+	}
+	for _, n := range q.parseErrors {
+		out.ParseErrors.Total += n
+	}
+
+	for i, col := range q.header {
+		if len(q.methods[i]) > 0 {
+			out.MatchMethods[col] = q.methods[i]
+		}
+	}
+
+	keys := make([][2]string, 0, len(q.groups))
+	for key := range q.groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		group := q.groups[key]
+		rates := make(map[string]float64, len(q.header))
+		for i, col := range q.header {
+			if isReferenceColumn(col) {
+				continue
+			}
+			rates[col] = math.Round(float64(group.filled[i])/float64(group.filings)*10000) / 10000
+		}
+		out.FillRates = append(out.FillRates, fillRateOutput{
+			ReturnType: key[0],
+			TaxYear:    key[1],
+			Filings:    group.filings,
+			Columns:    rates,
+		})
+	}
+
+	for i, col := range q.header {
+		stats := q.numeric[i]
+		if stats == nil || stats.values == 0 {
+			continue
+		}
+		q1, q3 := stats.quantile(0.25), stats.quantile(0.75)
+		iqr := math.Max(q3-q1, 1)
+		lower, upper := q1-3*iqr, q3+3*iqr
+
+		result := outliersOutput{
+			Values:     stats.values,
+			LowerFence: fromLogScale(lower),
+			UpperFence: fromLogScale(upper),
+		}
+		for bucket, n := range stats.buckets {
+			if scaled := float64(bucket) / outlierBucketsPerDecade; scaled < lower || scaled > upper {
+				result.Outliers += n
+			}
+		}
+		for _, v := range append(append([]outlierValue{}, stats.highest...), stats.lowest...) {
+			if scaled := logScale(v.Value); scaled < lower || scaled > upper {
+				result.Examples = append(result.Examples, v)
+			}
+		}
+		if result.Outliers > 0 {
+			out.Outliers[col] = result
+		}
+	}
+
+	return out
+}
+
+// fromLogScale is the inverse of logScale, rounded to a whole value
+func fromLogScale(scaled float64) int64 {
+	v := math.Pow(10, math.Abs(scaled)) - 1
+	if scaled < 0 {
+		v = -v
+	}
+	if v > math.MaxInt64 || v < math.MinInt64 {
+		return int64(math.Copysign(math.MaxInt64, v))
+	}
+	return int64(math.Round(v))
+}
+
+// summary renders the report as text
+func (out qualityOutput) summary() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Data quality report: %d filings\n", out.Filings)
+
+	fmt.Fprintf(&b, "\nMissing identifiers: %d filings\n", out.MissingIdentifiers.Filings)
+	for _, col := range identifierColumns {
+		fmt.Fprintf(&b, "  %-12s %10d\n", col, out.MissingIdentifiers.ByColumn[col])
+	}
+
+	fmt.Fprintf(&b, "\nParse failures: %d\n", out.ParseErrors.Total)
+	for _, path := range sortedByCount(out.ParseErrors.ByElementPath, 10) {
+		fmt.Fprintf(&b, "  %10d  %s\n", out.ParseErrors.ByElementPath[path], path)
+	}
+
+	fmt.Fprintf(&b, "\nMatch methods:\n")
+	fmt.Fprintf(&b, "  %-36s %10s %10s %10s\n", "Column", "Exact", "Heuristic", "Derived")
+	var columns []string
+	for col := range out.MatchMethods {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	for _, col := range columns {
+		m := out.MatchMethods[col]
+		fmt.Fprintf(&b, "  %-36s %10d %10d %10d\n", col, m[matchExact], m[matchHeuristic], m[matchDerived])
+	}
+
+	fmt.Fprintf(&b, "\nFill rates (columns filled in at least one filing):\n")
+	for _, group := range out.FillRates {
+		filled := 0
+		for _, rate := range group.Columns {
+			if rate > 0 {
+				filled++
+			}
+		}
+		fmt.Fprintf(&b, "  %-8s %-6s %10d filings %5d of %d columns\n", group.ReturnType, group.TaxYear, group.Filings, filled, len(group.Columns))
+	}
+
+	fmt.Fprintf(&b, "\nNumeric outliers:\n")
+	columns = columns[:0]
+	for col := range out.Outliers {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	for _, col := range columns {
+		o := out.Outliers[col]
+		fmt.Fprintf(&b, "  %-36s %10d of %d outside [%d, %d]\n", col, o.Outliers, o.Values, o.LowerFence, o.UpperFence)
+		for _, v := range o.Examples {
+			fmt.Fprintf(&b, "      %20d  %s\n", v.Value, v.FileName)
+		}
+	}
+
+	return b.String()
+}
+
+// sortedByCount returns up to limit keys of counts, largest count first
+func sortedByCount(counts map[string]int, limit int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// WriteQualityReport writes the quality report of the run to basePath.json and
+// basePath.txt and prints the summary
+func (p *XMLToCSVProcessor) WriteQualityReport(basePath string) error {
+	out := p.quality.output()
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quality report: %w", err)
+	}
+	if err := os.WriteFile(basePath+".json", data, 0644); err != nil {
+		return fmt.Errorf("failed to write quality report: %w", err)
+	}
+
+	summary := out.summary()
+	if err := os.WriteFile(basePath+".txt", []byte(summary), 0644); err != nil {
+		return fmt.Errorf("failed to write quality summary: %w", err)
+	}
+	fmt.Print("\n" + summary)
+	return nil
+}
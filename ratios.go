@@ -0,0 +1,161 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Financial health ratios, computed from main record columns for every filing
+// and every panel row:
+//
+//	ProgramExpenseRatio     program service expenses / total functional expenses (Part IX line 25, B / A)
+//	FundraisingEfficiency   fundraising expenses / contributions (Part IX 25D / Part VIII 1h), cost per dollar raised
+//	AdministrativeOverhead  management and general expenses / total functional expenses (Part IX 25C / 25A)
+//	MonthsOfReserves        net assets without donor restrictions / monthly expenses (Part X 27 EOY / (Part IX 25A / 12))
+//	LiabilitiesToAssets     total liabilities / total assets, end of year (Part X 26 / 16)
+//	RevenueConcentration    Herfindahl index of contributions, program service revenue, investment income
+//	                        and all other revenue (Part VIII 1h, 2g, 3 and the rest of line 12), 1 = one source
+//	SurplusMargin           (total revenue - total expenses) / total revenue (Part VIII 12A, Part IX 25A)
+//
+// A ratio is empty when an input is missing or its denominator is zero or
+// negative, and RatioFlags lists the reason per ratio, e.g.
+// "SurplusMargin:zero_denominator;MonthsOfReserves:missing_input". Missing
+// amounts are not taken as zero, except the revenue sources of
+// RevenueConcentration, which are parts of a reported total.
+
+var ratioColumns = []string{
+	"ProgramExpenseRatio",
+	"FundraisingEfficiency",
+	"AdministrativeOverhead",
+	"MonthsOfReserves",
+	"LiabilitiesToAssets",
+	"RevenueConcentration",
+	"SurplusMargin",
+	"RatioFlags",
+}
+
+// ratioInputs are the main record columns the ratios read
+var ratioInputs = []string{
+	"TotalExpenses",
+	"ExpensesForProgramServices",
+	"ExpensesForManagement",
+	"ExpensesForFundraising",
+	"TotalRevenue",
+	"RevenueFromContributions",
+	"RevenueFromProgramServices",
+	"RevenueFromInvestment",
+	"AssetsEOY",
+	"LiabilitiesEOY",
+	"UnrestrictedNetAssetsEOY",
+}
+
+// computeRatios returns the ratio columns for the ratio input values
+func computeRatios(values map[string]string) map[string]string {
+	ratios := make(map[string]string, len(ratioColumns))
+	var flags []string
+
+	set := func(name string, numerator []string, denominator string, scale float64) {
+		var num int64
+		for _, col := range numerator {
+			amount, ok := parseAmount(values[col])
+			if !ok {
+				flags = append(flags, name+":missing_input")
+				return
+			}
+			num += amount
+		}
+		den, ok := parseAmount(values[denominator])
+		switch {
+		case !ok:
+			flags = append(flags, name+":missing_input")
+		case den == 0:
+			flags = append(flags, name+":zero_denominator")
+		case den < 0:
+			flags = append(flags, name+":negative_denominator")
+		default:
+			ratios[name] = formatRatio(float64(num) * scale / float64(den))
+		}
+	}
+
+	set("ProgramExpenseRatio", []string{"ExpensesForProgramServices"}, "TotalExpenses", 1)
+	set("FundraisingEfficiency", []string{"ExpensesForFundraising"}, "RevenueFromContributions", 1)
+	set("AdministrativeOverhead", []string{"ExpensesForManagement"}, "TotalExpenses", 1)
+	set("MonthsOfReserves", []string{"UnrestrictedNetAssetsEOY"}, "TotalExpenses", 12)
+	set("LiabilitiesToAssets", []string{"LiabilitiesEOY"}, "AssetsEOY", 1)
+
+	if concentration, flag := revenueConcentration(values); flag != "" {
+		flags = append(flags, "RevenueConcentration:"+flag)
+	} else {
+		ratios["RevenueConcentration"] = concentration
+	}
+
+	// Surplus margin keeps negative results, so the numerator is computed here
+	revenue, okR := parseAmount(values["TotalRevenue"])
+	expenses, okE := parseAmount(values["TotalExpenses"])
+	switch {
+	case !okR || !okE:
+		flags = append(flags, "SurplusMargin:missing_input")
+	case revenue == 0:
+		flags = append(flags, "SurplusMargin:zero_denominator")
+	case revenue < 0:
+		flags = append(flags, "SurplusMargin:negative_denominator")
+	default:
+		ratios["SurplusMargin"] = formatRatio(float64(revenue-expenses) / float64(revenue))
+	}
+
+	ratios["RatioFlags"] = strings.Join(flags, ";")
+	return ratios
+}
+
+// revenueConcentration is the sum of squared shares of the revenue sources.
+// Negative sources (investment losses) are left out of shares and total.
+func revenueConcentration(values map[string]string) (string, string) {
+	total, ok := parseAmount(values["TotalRevenue"])
+	if !ok {
+		return "", "missing_input"
+	}
+
+	other := total
+	var sources []int64
+	for _, col := range []string{"RevenueFromContributions", "RevenueFromProgramServices", "RevenueFromInvestment"} {
+		amount, _ := parseAmount(values[col])
+		other -= amount
+		sources = append(sources, amount)
+	}
+	sources = append(sources, other)
+
+	var positive int64
+	for _, amount := range sources {
+		if amount > 0 {
+			positive += amount
+		}
+	}
+	if positive == 0 {
+		return "", "zero_denominator"
+	}
+
+	var index float64
+	for _, amount := range sources {
+		if amount > 0 {
+			share := float64(amount) / float64(positive)
+			index += share * share
+		}
+	}
+	return formatRatio(index), ""
+}
+
+// formatRatio renders a ratio with four decimals
+func formatRatio(ratio float64) string {
+	return strconv.FormatFloat(ratio, 'f', 4, 64)
+}
+
+// summarizeRatios fills the ratio columns of the main record
+func (p *XMLToCSVProcessor) summarizeRatios(record []string) {
+	values := make(map[string]string, len(ratioInputs))
+	for _, col := range ratioInputs {
+		values[col] = record[p.fieldMap[col]]
+	}
+	for col, ratio := range computeRatios(values) {
+		record[p.fieldMap[col]] = ratio
+	}
+}
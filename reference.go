@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Reference data joined to filings by EIN after extraction. The main CSV is
+// rewritten with the reference columns filled and panel rows carry them too.
+// The columns are empty while filings are extracted, so the quality report
+// leaves them out of fill rates and provenance does not list them.
+
+// referenceSet is one reference data set loaded for the EINs of a run
+type referenceSet struct {
+	name   string
+	found  int                                              // EINs with data
+	lookup func(ein, taxPeriodEnd string) map[string]string // column -> value, nil when nothing was downloaded
+}
+
+// referenceColumns are the main CSV columns filled from reference sets
+var referenceColumns = append(append([]string{}, bmfColumns...), eligibilityColumns...)
+
+// SyncReferenceData downloads this month's snapshots of the reference data
+func SyncReferenceData() error {
+	fmt.Println("Checking Business Master File...")
+	if err := downloadSnapshot(bmfDir, bmfBaseURL, bmfFiles); err != nil {
+		return err
+	}
+	fmt.Println("Checking Pub 78 and automatic revocation lists...")
+	return downloadSnapshot(eligibilityDir, eligibilityBaseURL, []string{pub78File, revocationFile})
+}
+
+func isReferenceColumn(col string) bool {
+	for _, c := range referenceColumns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// loadReferenceSets loads the reference data of the EINs of the run
+func loadReferenceSets(versions []filingVersion) ([]referenceSet, error) {
+	eins := make(map[string]bool)
+	for _, v := range versions {
+		if v.ein != "" {
+			eins[v.ein] = true
+		}
+	}
+
+	bmf, err := loadBusinessMasterFile(bmfDir, eins)
+	if err != nil {
+		return nil, err
+	}
+	eligibility, err := loadEligibility(eligibilityDir, eins)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := []referenceSet{bmf, eligibility}
+	for _, set := range sets {
+		if set.lookup == nil {
+			log.Printf("%s: not downloaded, run sync", set.name)
+		} else {
+			log.Printf("%s: %d of %d EINs found", set.name, set.found, len(eins))
+		}
+	}
+	return sets, nil
+}
+
+// joinReferenceSets fills the reference columns of the main CSV
+func joinReferenceSets(outputPath string, sets []referenceSet) error {
+	loaded := false
+	for _, set := range sets {
+		loaded = loaded || set.lookup != nil
+	}
+	if !loaded {
+		return nil
+	}
+
+	colIndex := make(map[string]int)
+	return rewriteCSV(outputPath, func(row []string, header bool) bool {
+		if header {
+			for i, col := range row {
+				colIndex[col] = i
+			}
+			return true
+		}
+		fillReferenceColumns(sets, row[colIndex["EIN"]], row[colIndex["TaxPeriodEnd"]], func(col, value string) {
+			if idx, ok := colIndex[col]; ok && idx < len(row) {
+				row[idx] = value
+			}
+		})
+		return true
+	})
+}
+
+// fillReferenceColumns passes the reference values of a filing to set
+func fillReferenceColumns(sets []referenceSet, ein, taxPeriodEnd string, set func(col, value string)) {
+	if ein == "" {
+		return
+	}
+	for _, s := range sets {
+		if s.lookup == nil {
+			continue
+		}
+		for col, value := range s.lookup(ein, taxPeriodEnd) {
+			set(col, value)
+		}
+	}
+}
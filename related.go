@@ -0,0 +1,140 @@
+package main
+
+import "strconv"
+
+// Schedule R (related organizations) and Schedule L (transactions with
+// interested persons) tables. Element names follow the 2013+ efile schemas.
+
+const (
+	scheduleRPath = "Return.ReturnData.IRS990ScheduleR."
+	scheduleLPath = "Return.ReturnData.IRS990ScheduleL."
+)
+
+var scheduleRTable = tableSpec{
+	name: "schedule_r",
+	columns: []string{
+		"Part",
+		"Relationship",
+		"EntityName",
+		"EntityEIN",
+		"PrimaryActivity",
+		"LegalDomicileState",
+		"LegalDomicileCountry",
+		"ExemptCodeSection",
+		"PublicCharityStatus",
+		"EntityType",
+		"DirectControllingEntity",
+		"ControlledOrganization",
+		"PredominantIncome",
+		"TotalIncome",
+		"EndOfYearAssets",
+		"OwnershipPct",
+		"TransactionType",
+		"TransactionAmount",
+		"AmountDeterminationMethod",
+	},
+	groups: []tableGroup{
+		{path: scheduleRPath + "IdDisregardedEntitiesGrp", values: map[string]string{"Part": "I", "Relationship": "DisregardedEntity"}},
+		{path: scheduleRPath + "IdRelatedTaxExemptOrgGrp", values: map[string]string{"Part": "II", "Relationship": "RelatedTaxExemptOrg"}},
+		{path: scheduleRPath + "IdRelatedOrgTxblPartnershipGrp", values: map[string]string{"Part": "III", "Relationship": "RelatedTaxablePartnership"}},
+		{path: scheduleRPath + "IdRelatedOrgTxblCorpTrGrp", values: map[string]string{"Part": "IV", "Relationship": "RelatedTaxableCorpOrTrust"}},
+		{path: scheduleRPath + "TransactionsRelatedOrgGrp", values: map[string]string{"Part": "V", "Relationship": "TransactionWithRelatedOrg"}},
+	},
+	fields: map[string]string{
+		"DisregardedEntityName.BusinessNameLine1Txt":   "EntityName",
+		"RelatedOrganizationName.BusinessNameLine1Txt": "EntityName",
+		"OtherOrganizationName.BusinessNameLine1Txt":   "EntityName",
+		"EIN":                           "EntityEIN",
+		"PrimaryActivitiesTxt":          "PrimaryActivity",
+		"LegalDomicileStateCd":          "LegalDomicileState",
+		"LegalDomicileForeignCountryCd": "LegalDomicileCountry",
+		"ExemptCodeSectionTxt":          "ExemptCodeSection",
+		"PublicCharityStatusTxt":        "PublicCharityStatus",
+		"EntityTypeTxt":                 "EntityType",
+		"DirectControllingEntityName.BusinessNameLine1Txt": "DirectControllingEntity",
+		"DirectControllingNACd":                            "DirectControllingEntity",
+		"ControlledOrganizationInd":                        "ControlledOrganization",
+		"SectionControlledEntityInd":                       "ControlledOrganization",
+		"PredominantIncomeDesc":                            "PredominantIncome",
+		"TotalIncomeAmt":                                   "TotalIncome",
+		"ShareOfTotalIncomeAmt":                            "TotalIncome",
+		"EndOfYearAssetsAmt":                               "EndOfYearAssets",
+		"ShareOfEOYAssetsAmt":                              "EndOfYearAssets",
+		"OwnershipPct":                                     "OwnershipPct",
+		"TransactionTypeTxt":                               "TransactionType",
+		"InvolvedAmt":                                      "TransactionAmount",
+		"MethodOfAmountDeterminationTxt":                   "AmountDeterminationMethod",
+	},
+}
+
+var scheduleLTable = tableSpec{
+	name: "schedule_l",
+	columns: []string{
+		"Part",
+		"PersonName",
+		"Relationship",
+		"Description",
+		"LoanToOrganization",
+		"LoanFromOrganization",
+		"OriginalPrincipal",
+		"BalanceDue",
+		"InDefault",
+		"BoardApproved",
+		"WrittenAgreement",
+		"AssistanceType",
+		"TransactionAmount",
+		"SharingOfRevenues",
+		"Corrected",
+	},
+	groups: []tableGroup{
+		{path: scheduleLPath + "DisqualifiedPersonExBnftTrGrp", values: map[string]string{"Part": "I"}},
+		{path: scheduleLPath + "LoansBtwnOrgInterestedPrsnGrp", values: map[string]string{"Part": "II"}},
+		{path: scheduleLPath + "GrntAsstBnftInterestedPrsnGrp", values: map[string]string{"Part": "III"}},
+		{path: scheduleLPath + "BusTrInvolveInterestedPrsnGrp", values: map[string]string{"Part": "IV"}},
+	},
+	fields: map[string]string{
+		"NameOfDisqualifiedPersonTxt":                        "PersonName",
+		"PersonNm":                                           "PersonName",
+		"BusinessName.BusinessNameLine1Txt":                  "PersonName",
+		"NameOfInterested.PersonNm":                          "PersonName",
+		"NameOfInterested.BusinessName.BusinessNameLine1Txt": "PersonName",
+		"RelationshipDescriptionTxt":                         "Relationship",
+		"RelationshipWithOrgTxt":                             "Relationship",
+		"TransactionDesc":                                    "Description",
+		"LoanPurposeTxt":                                     "Description",
+		"AssistancePurposeTxt":                               "Description",
+		"LoanToOrganizationInd":                              "LoanToOrganization",
+		"LoanFromOrganizationInd":                            "LoanFromOrganization",
+		"OriginalPrincipalAmt":                               "OriginalPrincipal",
+		"BalanceDueAmt":                                      "BalanceDue",
+		"DefaultInd":                                         "InDefault",
+		"BoardApprovalInd":                                   "BoardApproved",
+		"WrittenAgreementInd":                                "WrittenAgreement",
+		"TypeOfAssistanceTxt":                                "AssistanceType",
+		"CashGrantAmt":                                       "TransactionAmount",
+		"TransactionAmt":                                     "TransactionAmount",
+		"SharingOfRevenuesInd":                               "SharingOfRevenues",
+		"TransactionCorrectedInd":                            "Corrected",
+	},
+}
+
+// summarizeRelated fills the related-party summary columns of the main record
+// from the Schedule R and Schedule L rows of the filing
+func (p *XMLToCSVProcessor) summarizeRelated(tables *tableTracker, record []string) {
+	counts := map[string]int{
+		"Subsidiaries":         tables.countRows(scheduleRTable.name, "Part", "I"),
+		"RelatedOrganizations": tables.countRows(scheduleRTable.name, "Part", "II", "III", "IV"),
+		"LoansToOfficers":      tables.countIndicatorRows(scheduleLTable.name, "LoanFromOrganization"),
+		"LoansFromOfficers":    tables.countIndicatorRows(scheduleLTable.name, "LoanToOrganization"),
+		"BusinessTransactions": tables.countRows(scheduleLTable.name, "Part", "IV"),
+	}
+
+	for field, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if idx, ok := p.fieldMap[field]; ok {
+			record[idx] = strconv.Itoa(count)
+		}
+	}
+}
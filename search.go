@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SearchEIN prints what the outputs and the reference data hold for one EIN:
+// its filings in the main CSV, its Business Master File classification and
+// its Pub 78 and revocation status in every snapshot. With scan it also reads
+// every XML file for EIN elements naming it, which finds the filings of other
+// organizations that list it (Schedule R, grantees, ...).
+func SearchEIN(ein string, scan bool) error {
+	ein = strings.ReplaceAll(strings.TrimSpace(ein), "-", "")
+	if len(ein) != 9 || strings.Trim(ein, "0123456789") != "" {
+		return fmt.Errorf("invalid EIN %q, expected 9 digits", ein)
+	}
+	eins := map[string]bool{ein: true}
+
+	fmt.Printf("EIN %s\n", ein)
+
+	fmt.Println("\nFilings in irs_990_data.csv:")
+	if err := printFilings("irs_990_data.csv", ein); err != nil {
+		fmt.Printf("  %v\n", err)
+	}
+
+	fmt.Println("\nBusiness Master File:")
+	bmf, err := readBusinessMasterFile(bmfDir, eins)
+	if err != nil {
+		return err
+	}
+	if len(bmf[ein]) == 0 {
+		fmt.Println("  not found in any snapshot")
+	}
+	for _, entry := range bmf[ein] {
+		v := entry.values
+		fmt.Printf("  %s  NTEE %-5s subsection %-3s foundation %-3s ruling %-8s affiliation %-2s deductibility %s\n",
+			entry.snapshot, v["NTEECode"], v["Subsection"], v["FoundationCode"], v["RulingDate"], v["Affiliation"], v["Deductibility"])
+	}
+
+	fmt.Println("\nPub 78 and automatic revocation:")
+	snapshots, err := readEligibility(eligibilityDir, eins)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("  no snapshots, run sync")
+	}
+	for _, s := range snapshots {
+		status := s.status(ein, "")
+		pub78 := "unknown"
+		if status["Pub78Eligible"] == "true" {
+			pub78 = "listed (" + status["Pub78Deductibility"] + ")"
+		} else if status["Pub78Eligible"] == "false" {
+			pub78 = "not listed"
+		}
+		revoked := "unknown"
+		if status["AutoRevoked"] == "true" {
+			var entries []string
+			for _, r := range s.revocations[ein] {
+				entry := "revoked " + r.date + ", posted " + r.postingDate
+				if r.reinstatement != "" {
+					entry += ", reinstated " + r.reinstatement
+				}
+				entries = append(entries, entry)
+			}
+			revoked = strings.Join(entries, "; ")
+		} else if status["AutoRevoked"] == "false" {
+			revoked = "not revoked"
+		}
+		fmt.Printf("  %s  Pub 78: %-24s revocation: %s\n", s.date, pub78, revoked)
+	}
+
+	if scan {
+		fmt.Println("\nXML files mentioning the EIN:")
+		return scanXMLFiles("data/990_zips", ein)
+	}
+	return nil
+}
+
+// scanXMLFiles prints every EIN element under dir equal to ein, with its file
+// and element path
+func scanXMLFiles(dir, ein string) error {
+	processed, found := 0, 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(path), ".xml") {
+			return nil
+		}
+
+		processed++
+		if processed%10000 == 0 {
+			log.Printf("Scanned %d files, %d matches", processed, found)
+		}
+		paths, err := einElementPaths(path, ein)
+		if err != nil {
+			log.Printf("Error scanning %s: %v", path, err)
+		}
+		for _, elementPath := range paths {
+			found++
+			fmt.Printf("  %s  %s\n", path, elementPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	fmt.Printf("  %d matches in %d files\n", found, processed)
+	return nil
+}
+
+// einElementPaths returns the paths of the EIN elements of a file equal to ein
+func einElementPaths(path, ein string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	var pathStack, matches []string
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return matches, nil
+		}
+		if err != nil {
+			return matches, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			pathStack = append(pathStack, t.Name.Local)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if t.Name.Local == "EIN" && strings.TrimSpace(text.String()) == ein {
+				matches = append(matches, strings.Join(pathStack, "."))
+			}
+			if len(pathStack) > 0 {
+				pathStack = pathStack[:len(pathStack)-1]
+			}
+			text.Reset()
+		}
+	}
+}
+
+// printFilings lists the rows of the main CSV with the given EIN
+func printFilings(path, ein string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s, run csv first", path)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s header: %w", path, err)
+	}
+	colIndex := make(map[string]int, len(header))
+	for i, col := range header {
+		colIndex[col] = i
+	}
+
+	found := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if row[colIndex["EIN"]] != ein {
+			continue
+		}
+		found++
+		fmt.Printf("  %-4s  %-6s %-10s  %s  %s\n", row[colIndex["TaxYear"]], row[colIndex["ReturnType"]],
+			row[colIndex["TaxPeriodEnd"]], row[colIndex["FileName"]], row[colIndex["OrganizationName"]])
+	}
+	if found == 0 {
+		fmt.Println("  none")
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tableKeyColumns are prepended to every side table so rows can be joined back
+// to the filing they came from
+var tableKeyColumns = []string{"FileName", "EIN", "TaxYear"}
+
+// tableGroup is a repeating XML group whose every occurrence becomes one row
+type tableGroup struct {
+	path   string            // full element path of the group, e.g. Return.ReturnData.IRS990ScheduleR.IdDisregardedEntitiesGrp
+	values map[string]string // constant column values written for each row of this group
+}
+
+// tableSpec describes a normalized side table extracted alongside the main CSV
+type tableSpec struct {
+	name    string                      // output file suffix
+	columns []string                    // columns after the key columns
+	groups  []tableGroup                // groups that produce rows
+	fields  map[string]string           // leaf path relative to the group -> column
+	shared  map[string]string           // full leaf path outside the groups -> column copied onto every row
+	derive  func(row map[string]string) // optional, fills computed columns when a row closes
+}
+
+// tableSpecs lists every side table written by the CSV processor
+var tableSpecs = []*tableSpec{
+	&scheduleRTable,
+	&scheduleLTable,
+	&partVIITable,
+	&scheduleJTable,
+	&partVIIITable,
+	&partIXTable,
+	&partXTable,
+	&programsTable,
+	&scheduleHBenefitsTable,
+	&scheduleHPartIIITable,
+	&scheduleHFacilitiesTable,
+	&scheduleFTable,
+	&scheduleCTable,
+	&scheduleDFundsTable,
+	&scheduleDEndowmentTable,
+	&scheduleDInvestmentsTable,
+	&scheduleDReconciliationTable,
+	&scheduleASupportTable,
+	&scheduleAStatusTable,
+	&addressesTable,
+	&narrativeTable,
+	&documentsTable,
+	&documentReferencesTable,
+	&parseErrorsTable,
+	&validationTable,
+}
+
+// tableWriter owns the output file of one side table
+type tableWriter struct {
+	spec      *tableSpec
+	file      *os.File
+	csvWriter *csv.Writer
+	colIndex  map[string]int
+}
+
+// tablePath is the output file of spec next to the main CSV
+func tablePath(outputPath string, spec *tableSpec) string {
+	return strings.TrimSuffix(outputPath, ".csv") + "_" + spec.name + ".csv"
+}
+
+// newTableWriter creates the output file for spec next to the main CSV
+func newTableWriter(outputPath string, spec *tableSpec) (*tableWriter, error) {
+	path := tablePath(outputPath, spec)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create table file %s: %w", path, err)
+	}
+
+	header := append(append([]string{}, tableKeyColumns...), spec.columns...)
+	colIndex := make(map[string]int, len(header))
+	for i, col := range header {
+		colIndex[col] = i
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write table header %s: %w", path, err)
+	}
+
+	return &tableWriter{
+		spec:      spec,
+		file:      file,
+		csvWriter: writer,
+		colIndex:  colIndex,
+	}, nil
+}
+
+// Close flushes and closes the table file
+func (t *tableWriter) Close() error {
+	t.csvWriter.Flush()
+	if err := t.csvWriter.Error(); err != nil {
+		t.file.Close()
+		return fmt.Errorf("failed to flush table %s: %w", t.spec.name, err)
+	}
+	return t.file.Close()
+}
+
+// tableRow is a row being filled while its group element is open
+type tableRow struct {
+	spec   *tableSpec
+	depth  int // length of the path stack when the group element opened
+	values map[string]string
+}
+
+// tableTracker follows open groups while a file is decoded
+type tableTracker struct {
+	groups map[string][]tableGroupRef
+	open   []*tableRow
+	rows   map[string][]map[string]string // table name -> finished rows
+	shared map[string]map[string]string   // table name -> filing level values
+
+	narrative  narrativeCollector
+	documents  documentCollector
+	validation validationCollector
+}
+
+type tableGroupRef struct {
+	spec  *tableSpec
+	group tableGroup
+}
+
+// tableGroupIndex maps group paths to their specs, built once at startup
+var tableGroupIndex = buildTableGroupIndex(tableSpecs)
+
+// tableSharedIndex maps shared leaf paths to the specs that copy them
+var tableSharedIndex = buildTableSharedIndex(tableSpecs)
+
+func buildTableGroupIndex(specs []*tableSpec) map[string][]tableGroupRef {
+	index := make(map[string][]tableGroupRef)
+	for _, spec := range specs {
+		for _, group := range spec.groups {
+			index[group.path] = append(index[group.path], tableGroupRef{spec: spec, group: group})
+		}
+	}
+	return index
+}
+
+func buildTableSharedIndex(specs []*tableSpec) map[string][]*tableSpec {
+	index := make(map[string][]*tableSpec)
+	for _, spec := range specs {
+		for path := range spec.shared {
+			index[path] = append(index[path], spec)
+		}
+	}
+	return index
+}
+
+func newTableTracker() *tableTracker {
+	return &tableTracker{
+		groups: tableGroupIndex,
+		rows:   make(map[string][]map[string]string),
+		shared: make(map[string]map[string]string),
+		documents: documentCollector{
+			names: make(map[string]string),
+		},
+	}
+}
+
+// start is called for every start element with the updated path stack
+func (t *tableTracker) start(pathStack []string) {
+	if row := t.documents.start(pathStack); row != nil {
+		t.rows[documentsTable.name] = append(t.rows[documentsTable.name], row)
+	}
+
+	for _, ref := range t.groups[strings.Join(pathStack, ".")] {
+		values := make(map[string]string, len(ref.group.values))
+		for col, val := range ref.group.values {
+			values[col] = val
+		}
+		t.open = append(t.open, &tableRow{spec: ref.spec, depth: len(pathStack), values: values})
+	}
+}
+
+// leaf records a leaf value against every open row it belongs to
+func (t *tableTracker) leaf(pathStack []string, fullPath, value string) {
+	t.setShared(fullPath, value)
+	t.validation.leaf(fullPath, value)
+
+	if row := t.narrative.leaf(pathStack, fullPath, value); row != nil {
+		t.rows[narrativeTable.name] = append(t.rows[narrativeTable.name], row)
+	}
+
+	t.setOpen(pathStack, "", value)
+}
+
+// attrs records the attributes of the element on top of the path stack. Spec
+// fields and shared paths address them as <path>@<attribute>.
+func (t *tableTracker) attrs(pathStack []string, attrs []xml.Attr) {
+	elementPath := strings.Join(pathStack, ".")
+	for _, attr := range attrs {
+		if isNamespaceAttr(attr) {
+			continue
+		}
+		t.setShared(elementPath+"@"+attr.Name.Local, attr.Value)
+		t.setOpen(pathStack, "@"+attr.Name.Local, attr.Value)
+	}
+
+	rows := t.documents.attrs(pathStack, attrs)
+	t.rows[documentReferencesTable.name] = append(t.rows[documentReferencesTable.name], rows...)
+}
+
+// parseError records a leaf value that failed normalization
+func (t *tableTracker) parseError(fullPath, value string, err error) {
+	t.rows[parseErrorsTable.name] = append(t.rows[parseErrorsTable.name], map[string]string{
+		"ElementPath": fullPath,
+		"Value":       value,
+		"Error":       err.Error(),
+	})
+}
+
+// setShared stores a filing level value for every spec sharing fullPath
+func (t *tableTracker) setShared(fullPath, value string) {
+	for _, spec := range tableSharedIndex[fullPath] {
+		if t.shared[spec.name] == nil {
+			t.shared[spec.name] = make(map[string]string)
+		}
+		t.shared[spec.name][spec.shared[fullPath]] = value
+	}
+}
+
+// setOpen stores a value in the open rows whose fields hold its relative path
+func (t *tableTracker) setOpen(pathStack []string, suffix, value string) {
+	for _, row := range t.open {
+		rel := strings.Join(pathStack[row.depth:], ".") + suffix
+		col, ok := row.spec.fields[rel]
+		if !ok {
+			continue
+		}
+		// Keep the first value, repeated elements must not overwrite it
+		if _, set := row.values[col]; !set {
+			row.values[col] = value
+		}
+	}
+}
+
+// end is called before the path stack is popped and closes finished rows
+func (t *tableTracker) end(pathStack []string) {
+	t.narrative.end(pathStack)
+
+	for len(t.open) > 0 {
+		row := t.open[len(t.open)-1]
+		if row.depth != len(pathStack) {
+			return
+		}
+		t.open = t.open[:len(t.open)-1]
+		if row.spec.derive != nil {
+			row.spec.derive(row.values)
+		}
+		t.rows[row.spec.name] = append(t.rows[row.spec.name], row.values)
+	}
+}
+
+// countRows returns the number of rows of a table whose column equals one of values
+func (t *tableTracker) countRows(table, column string, values ...string) int {
+	count := 0
+	for _, row := range t.rows[table] {
+		for _, v := range values {
+			if row[column] == v {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// countIndicatorRows returns the number of rows of a table whose indicator
+// column is set, whatever form (X, 1, true) the filing used
+func (t *tableTracker) countIndicatorRows(table, column string) int {
+	count := 0
+	for _, row := range t.rows[table] {
+		if value, err := normalizeIndicator(row[column]); err == nil && value == "true" {
+			count++
+		}
+	}
+	return count
+}
+
+// writeRows writes the rows of one filing, filling the key columns from the record
+// and the filing level values from shared
+func (t *tableWriter) writeRows(rows []map[string]string, shared map[string]string, keys []string) error {
+	for _, values := range rows {
+		out := make([]string, len(t.colIndex))
+		copy(out, keys)
+		for col, val := range shared {
+			if idx, ok := t.colIndex[col]; ok {
+				out[idx] = val
+			}
+		}
+		for col, val := range values {
+			if idx, ok := t.colIndex[col]; ok {
+				out[idx] = val
+			}
+		}
+		if err := t.csvWriter.Write(out); err != nil {
+			return fmt.Errorf("failed to write %s row: %w", t.spec.name, err)
+		}
+	}
+	return nil
+}
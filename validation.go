@@ -0,0 +1,303 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Arithmetic validation against the form math. A rule states that a reported
+// total equals the signed sum of other lines, on element paths under the form
+// document. Rules apply to one form and, where the form changed, to a range of
+// form versions (the year of the returnVersion attribute, e.g. 2018 for
+// 2018v3.1). A rule is checked when its total and at least one term are
+// present; missing terms count as zero since the e-file omits empty lines.
+// Every violated rule becomes a validation row with the expected (sum of terms)
+// and actual (reported total) values, and FailedRules lists them per filing.
+
+// validationRule checks total = sum of terms. Terms starting with "-" are
+// subtracted and "*" in a path stands for BOY and EOY, one rule each.
+type validationRule struct {
+	id          string
+	form        string // document element under ReturnData
+	from, to    int    // form version years, 0 for no bound
+	description string
+	total       string
+	terms       []string
+}
+
+var validationRules = expandValidationRules([]validationRule{
+	// Form 990 Part I
+	{"990_part_i_revenue", "IRS990", 0, 0, "Part I line 12 equals lines 8 through 11",
+		"CYTotalRevenueAmt", []string{"CYContributionsGrantsAmt", "CYProgramServiceRevenueAmt", "CYInvestmentIncomeAmt", "CYOtherRevenueAmt"}},
+	{"990_part_i_expenses", "IRS990", 0, 0, "Part I line 18 equals lines 13 through 16a and 17",
+		"CYTotalExpensesAmt", []string{"CYGrantsAndSimilarPaidAmt", "CYBenefitsPaidToMembersAmt", "CYSalariesCompEmpBnftPaidAmt", "CYTotalProfFndrsngExpnsAmt", "CYOtherExpensesAmt"}},
+	{"990_part_i_surplus", "IRS990", 0, 0, "Part I line 19 equals line 12 minus line 18",
+		"CYRevenuesLessExpensesAmt", []string{"CYTotalRevenueAmt", "-CYTotalExpensesAmt"}},
+	{"990_part_i_net_assets", "IRS990", 0, 0, "Part I line 22 equals line 20 minus line 21",
+		"NetAssetsOrFundBalances*Amt", []string{"TotalAssets*Amt", "-TotalLiabilities*Amt"}},
+
+	// Form 990 Part I against Parts III, VIII, IX and X
+	{"990_part_i_viii_revenue", "IRS990", 0, 0, "Part I line 12 equals Part VIII line 12 column A",
+		"CYTotalRevenueAmt", []string{"TotalRevenueGrp.TotalRevenueColumnAmt"}},
+	{"990_part_i_ix_expenses", "IRS990", 0, 0, "Part I line 18 equals Part IX line 25 column A",
+		"CYTotalExpensesAmt", []string{"TotalFunctionalExpensesGrp.TotalAmt"}},
+	{"990_part_iii_ix_program", "IRS990", 0, 0, "Part III line 4e equals Part IX line 25 column B",
+		"TotalProgramServiceExpensesAmt", []string{"TotalFunctionalExpensesGrp.ProgramServicesAmt"}},
+	{"990_part_i_x_assets", "IRS990", 0, 0, "Part I line 20 equals Part X line 16",
+		"TotalAssets*Amt", []string{"TotalAssetsGrp.*Amt"}},
+	{"990_part_i_x_liabilities", "IRS990", 0, 0, "Part I line 21 equals Part X line 26",
+		"TotalLiabilities*Amt", []string{"TotalLiabilitiesGrp.*Amt"}},
+	{"990_part_i_x_net_assets", "IRS990", 0, 0, "Part I line 22 equals Part X total net assets",
+		"NetAssetsOrFundBalances*Amt", []string{"TotalNetAssetsFundBalanceGrp.*Amt"}},
+
+	// Form 990 Part VIII
+	{"990_part_viii_contributions", "IRS990", 0, 0, "Part VIII line 1h equals lines 1a through 1f",
+		"TotalContributionsAmt", []string{"FederatedCampaignsAmt", "MembershipDuesAmt", "FundraisingAmt", "RelatedOrganizationsAmt", "GovernmentGrantsAmt", "AllOtherContributionsAmt"}},
+	{"990_part_viii_total", "IRS990", 0, 0, "Part VIII line 12 column A equals lines 1h, 2g, 3, 4, 5, 6d, 7d, 8c, 9c, 10c and 11e",
+		"TotalRevenueGrp.TotalRevenueColumnAmt", []string{
+			"TotalContributionsAmt",
+			"TotalProgramServiceRevenueAmt",
+			"InvestmentIncomeGrp.TotalRevenueColumnAmt",
+			"IncmFromInvestBondProceedsGrp.TotalRevenueColumnAmt",
+			"RoyaltiesRevenueGrp.TotalRevenueColumnAmt",
+			"NetRentalIncomeOrLossGrp.TotalRevenueColumnAmt",
+			"NetGainOrLossInvestmentsGrp.TotalRevenueColumnAmt",
+			"NetIncmFromFundraisingEvtGrp.TotalRevenueColumnAmt",
+			"NetIncomeFromGamingGrp.TotalRevenueColumnAmt",
+			"NetIncomeOrLossGrp.TotalRevenueColumnAmt",
+			"OtherRevenueTotalAmt",
+		}},
+	{"990_part_viii_columns", "IRS990", 0, 0, "Part VIII line 12 column A equals line 1h plus columns B, C and D",
+		"TotalRevenueGrp.TotalRevenueColumnAmt", []string{"TotalContributionsAmt", "TotalRevenueGrp.RelatedOrExemptFuncIncomeAmt", "TotalRevenueGrp.UnrelatedBusinessRevenueAmt", "TotalRevenueGrp.ExclusionAmt"}},
+
+	// Form 990 Part IX
+	{"990_part_ix_columns", "IRS990", 0, 0, "Part IX line 25 column A equals columns B, C and D",
+		"TotalFunctionalExpensesGrp.TotalAmt", []string{"TotalFunctionalExpensesGrp.ProgramServicesAmt", "TotalFunctionalExpensesGrp.ManagementAndGeneralAmt", "TotalFunctionalExpensesGrp.FundraisingAmt"}},
+
+	// Form 990 Part X
+	{"990_part_x_assets", "IRS990", 0, 0, "Part X line 16 equals lines 1 through 9, 10c and 11 through 15",
+		"TotalAssetsGrp.*Amt", []string{
+			"CashNonInterestBearingGrp.*Amt",
+			"SavingsAndTempCashInvstGrp.*Amt",
+			"PledgesAndGrantsReceivableGrp.*Amt",
+			"AccountsReceivableGrp.*Amt",
+			"ReceivablesFromOfficersEtcGrp.*Amt",
+			"RcvblFromDisqualifiedPrsnGrp.*Amt",
+			"OthNotesLoansReceivableNetGrp.*Amt",
+			"InventoriesForSaleOrUseGrp.*Amt",
+			"PrepaidExpensesDefrdChargesGrp.*Amt",
+			"LandBldgEquipBasisNetGrp.*Amt",
+			"InvestmentsPubTradedSecGrp.*Amt",
+			"InvestmentsOtherSecuritiesGrp.*Amt",
+			"InvestmentsProgramRelatedGrp.*Amt",
+			"IntangibleAssetsGrp.*Amt",
+			"OtherAssetsTotalGrp.*Amt",
+		}},
+	{"990_part_x_liabilities", "IRS990", 0, 0, "Part X line 26 equals lines 17 through 25",
+		"TotalLiabilitiesGrp.*Amt", []string{
+			"AccountsPayableAccrExpnssGrp.*Amt",
+			"GrantsPayableGrp.*Amt",
+			"DeferredRevenueGrp.*Amt",
+			"TaxExemptBondLiabilitiesGrp.*Amt",
+			"EscrowAccountLiabilityGrp.*Amt",
+			"LoansFromOfficersDirectorsGrp.*Amt",
+			"MortgNotesPyblScrdInvstPropGrp.*Amt",
+			"UnsecuredNotesLoansPayableGrp.*Amt",
+			"OtherLiabilitiesGrp.*Amt",
+		}},
+	{"990_part_x_net_assets", "IRS990", 2018, 0, "Part X line 32 equals lines 27 through 31",
+		"TotalNetAssetsFundBalanceGrp.*Amt", []string{
+			"NoDonorRestrictionNetAssetsGrp.*Amt",
+			"DonorRestrictionNetAssetsGrp.*Amt",
+			"CapStkTrPrinCurrentFundsGrp.*Amt",
+			"PdInCapSrplsLandBldgEqpFundGrp.*Amt",
+			"RtnEarnEndowmentIncmOthFndsGrp.*Amt",
+		}},
+	{"990_part_x_net_assets", "IRS990", 0, 2017, "Part X line 33 equals lines 27 through 32",
+		"TotalNetAssetsFundBalanceGrp.*Amt", []string{
+			"UnrestrictedNetAssetsGrp.*Amt",
+			"TemporarilyRstrNetAssetsGrp.*Amt",
+			"PermanentlyRstrNetAssetsGrp.*Amt",
+			"CapStkTrPrinCurrentFundsGrp.*Amt",
+			"PdInCapSrplsLandBldgEqpFundGrp.*Amt",
+			"RtnEarnEndowmentIncmOthFndsGrp.*Amt",
+		}},
+	{"990_part_x_balance", "IRS990", 0, 0, "Part X total liabilities and net assets equals total liabilities plus total net assets",
+		"TotLiabNetAssetsFundBalanceGrp.*Amt", []string{"TotalLiabilitiesGrp.*Amt", "TotalNetAssetsFundBalanceGrp.*Amt"}},
+	{"990_part_x_assets_balance", "IRS990", 0, 0, "Part X total assets equals total liabilities and net assets",
+		"TotalAssetsGrp.*Amt", []string{"TotLiabNetAssetsFundBalanceGrp.*Amt"}},
+
+	// Form 990-EZ
+	{"990ez_revenue", "IRS990EZ", 0, 0, "Part I line 9 equals lines 1 through 8",
+		"TotalRevenueAmt", []string{
+			"ContributionsGiftsGrantsEtcAmt",
+			"ProgramServiceRevenueAmt",
+			"MembershipDuesAmt",
+			"InvestmentIncomeAmt",
+			"GainOrLossFromSaleOfAssetsAmt",
+			"SpecialEventsNetIncomeLossAmt",
+			"GrossProfitLossSlsOfInvntryAmt",
+			"OtherRevenueTotalAmt",
+		}},
+	{"990ez_expenses", "IRS990EZ", 0, 0, "Part I line 17 equals lines 10 through 16",
+		"TotalExpensesAmt", []string{
+			"GrantsAndSimilarAmountsPaidAmt",
+			"BenefitsPaidToOrForMembersAmt",
+			"SalariesOtherCompEmplBnftAmt",
+			"FeesAndOthPymtToIndCntrctAmt",
+			"OccupancyRentUtltsAndMaintAmt",
+			"PrintingPublicationsPostageAmt",
+			"OtherExpensesTotalAmt",
+		}},
+	{"990ez_excess", "IRS990EZ", 0, 0, "Part I line 18 equals line 9 minus line 17",
+		"ExcessOrDeficitForYearAmt", []string{"TotalRevenueAmt", "-TotalExpensesAmt"}},
+	{"990ez_net_assets", "IRS990EZ", 0, 0, "Part I line 21 equals lines 18 through 20",
+		"NetAssetsOrFundBalancesEOYAmt", []string{"ExcessOrDeficitForYearAmt", "NetAssetsOrFundBalancesBOYAmt", "OtherChangesInNetAssetsAmt"}},
+	{"990ez_balance", "IRS990EZ", 0, 0, "Part II line 27 equals line 25 minus line 26",
+		"NetAssetsOrFundBalancesGrp.*Amt", []string{"Form990TotalAssetsGrp.*Amt", "-SumOfTotalLiabilitiesGrp.*Amt"}},
+	{"990ez_part_i_ii_net_assets", "IRS990EZ", 0, 0, "Part I line 21 equals Part II line 27 end of year",
+		"NetAssetsOrFundBalancesEOYAmt", []string{"NetAssetsOrFundBalancesGrp.EOYAmt"}},
+
+	// Form 990-PF Part I
+	{"990pf_expenses", "IRS990PF", 0, 0, "Part I line 26 column a equals lines 24 and 25",
+		"AnalysisOfRevenueAndExpenses.TotalExpensesRevAndExpnssAmt", []string{"AnalysisOfRevenueAndExpenses.TotalOprExpensesRevAndExpnssAmt", "AnalysisOfRevenueAndExpenses.ContriPaidRevAndExpnssAmt"}},
+	{"990pf_excess", "IRS990PF", 0, 0, "Part I line 27a equals line 12 minus line 26, column a",
+		"AnalysisOfRevenueAndExpenses.ExcessRevenueOverExpensesAmt", []string{"AnalysisOfRevenueAndExpenses.TotalRevAndExpnssAmt", "-AnalysisOfRevenueAndExpenses.TotalExpensesRevAndExpnssAmt"}},
+})
+
+var validationTable = tableSpec{
+	name: "validation",
+	columns: []string{
+		"Form",
+		"FormVersion",
+		"Rule",
+		"Description",
+		"Expected",
+		"Actual",
+		"Difference",
+	},
+	shared: map[string]string{
+		"Return@returnVersion": "FormVersion",
+	},
+}
+
+// expandValidationRules splits rules with "*" paths into BOY and EOY rules
+func expandValidationRules(rules []validationRule) []validationRule {
+	var expanded []validationRule
+	for _, rule := range rules {
+		if !strings.Contains(rule.total, "*") {
+			expanded = append(expanded, rule)
+			continue
+		}
+		for _, year := range []string{"BOY", "EOY"} {
+			r := rule
+			r.id = rule.id + "_" + strings.ToLower(year)
+			r.description = rule.description + ", " + strings.ToLower(year)
+			r.total = strings.ReplaceAll(rule.total, "*", year)
+			r.terms = make([]string, len(rule.terms))
+			for i, term := range rule.terms {
+				r.terms[i] = strings.ReplaceAll(term, "*", year)
+			}
+			expanded = append(expanded, r)
+		}
+	}
+	return expanded
+}
+
+// validationInputs maps every path the rules read to its <form>.<path> key
+var validationInputs = buildValidationInputs(validationRules)
+
+func buildValidationInputs(rules []validationRule) map[string]string {
+	inputs := make(map[string]string)
+	for _, rule := range rules {
+		for _, path := range append([]string{rule.total}, rule.terms...) {
+			path = strings.TrimPrefix(path, "-")
+			inputs["Return.ReturnData."+rule.form+"."+path] = rule.form + "." + path
+		}
+	}
+	return inputs
+}
+
+// validationCollector keeps the rule inputs of a filing while it is decoded.
+// They are not columns of any table, so they are kept apart from table specs.
+type validationCollector struct {
+	values map[string]string // <form>.<path> -> value
+}
+
+// leaf records the value of a rule input
+func (v *validationCollector) leaf(fullPath, value string) {
+	key, ok := validationInputs[fullPath]
+	if !ok {
+		return
+	}
+	if v.values == nil {
+		v.values = make(map[string]string)
+	}
+	v.values[key] = value
+}
+
+// formVersionYear returns the year of a returnVersion such as 2018v3.1
+func formVersionYear(version string) (int, bool) {
+	year, _, _ := strings.Cut(version, "v")
+	n, err := strconv.Atoi(year)
+	return n, err == nil
+}
+
+// appliesTo reports whether the rule covers a form version year. Rules limited
+// to a range of versions are skipped when the version is unknown.
+func (r validationRule) appliesTo(year int, known bool) bool {
+	if r.from == 0 && r.to == 0 {
+		return true
+	}
+	return known && (r.from == 0 || year >= r.from) && (r.to == 0 || year <= r.to)
+}
+
+// summarizeValidation checks every rule of the filing's form and version and
+// fills the validation rows and FailedRules
+func (p *XMLToCSVProcessor) summarizeValidation(tables *tableTracker, record []string) {
+	values := tables.validation.values
+	if len(values) == 0 {
+		return
+	}
+	year, knownYear := formVersionYear(tables.shared[validationTable.name]["FormVersion"])
+
+	var failed []string
+	for _, rule := range validationRules {
+		if !rule.appliesTo(year, knownYear) {
+			continue
+		}
+
+		actual, ok := parseAmount(values[rule.form+"."+rule.total])
+		if !ok {
+			continue
+		}
+		var expected int64
+		var found bool
+		for _, term := range rule.terms {
+			amount, ok := parseAmount(values[rule.form+"."+strings.TrimPrefix(term, "-")])
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(term, "-") {
+				amount = -amount
+			}
+			expected += amount
+			found = true
+		}
+		if !found || expected == actual {
+			continue
+		}
+
+		failed = append(failed, rule.id)
+		tables.rows[validationTable.name] = append(tables.rows[validationTable.name], map[string]string{
+			"Form":        rule.form,
+			"Rule":        rule.id,
+			"Description": rule.description,
+			"Expected":    strconv.FormatInt(expected, 10),
+			"Actual":      strconv.FormatInt(actual, 10),
+			"Difference":  strconv.FormatInt(actual-expected, 10),
+		})
+	}
+
+	record[p.fieldMap["FailedRules"]] = strings.Join(failed, ";")
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Amended and duplicate filing resolution. Filings of the same EIN and tax
+// period end are versions of one return; the authoritative version is the
+// amended one if any, then the latest by submission date (SUB_DATE of the IRS
+// index files, else the ReturnTs date), then by ReturnTs, then by object id.
+// Every other version is superseded. The csv -latest option drops superseded
+// filings from all outputs.
+
+var filingVersionsTable = tableSpec{
+	name: "filing_versions",
+	columns: []string{
+		"ObjectID",
+		"TaxPeriodEnd",
+		"AmendedReturn",
+		"ReturnTimestamp",
+		"SubmissionDate",
+		"Versions",
+		"VersionRank",
+		"Status",
+		"SupersededBy",
+	},
+}
+
+// filingVersion is what resolution needs to know about one filing
+type filingVersion struct {
+	fileName       string
+	objectID       string
+	ein            string
+	taxYear        string
+	taxPeriodEnd   string
+	returnTs       string
+	submissionDate string
+	amended        bool
+	panel          panelFiling
+}
+
+// indexDateLayouts are the SUB_DATE forms found in the IRS index files
+var indexDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"2006-01-02 15:04:05",
+}
+
+// filingVersionOf reads the resolution fields from a finished record
+func (p *XMLToCSVProcessor) filingVersionOf(record []string) filingVersion {
+	fileName := record[p.fieldMap["FileName"]]
+	return filingVersion{
+		fileName:     fileName,
+		objectID:     objectIDFromFileName(fileName),
+		ein:          record[p.fieldMap["EIN"]],
+		taxYear:      record[p.fieldMap["TaxYear"]],
+		taxPeriodEnd: record[p.fieldMap["TaxPeriodEnd"]],
+		returnTs:     record[p.fieldMap["FilingDate"]],
+		amended:      record[p.fieldMap["AmendedReturn"]] == "true",
+		panel:        p.panelFilingOf(record),
+	}
+}
+
+// loadSubmissionDates reads OBJECT_ID -> SUB_DATE from the index_*.csv files
+// downloaded by sync. Missing index files leave submission dates empty.
+func loadSubmissionDates(dir string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "index_*.csv"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index files: %w", err)
+	}
+
+	dates := make(map[string]string)
+	for _, path := range paths {
+		if err := readIndexFile(path, dates); err != nil {
+			log.Printf("Error reading index file %s: %v", path, err)
+		}
+	}
+	return dates, nil
+}
+
+func readIndexFile(path string, dates map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open index file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read index header: %w", err)
+	}
+	objectCol, dateCol := -1, -1
+	for i, col := range header {
+		switch strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")) {
+		case "OBJECT_ID":
+			objectCol = i
+		case "SUB_DATE":
+			dateCol = i
+		}
+	}
+	if objectCol < 0 || dateCol < 0 {
+		return fmt.Errorf("index file has no OBJECT_ID and SUB_DATE columns")
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read index row: %w", err)
+		}
+		if objectCol >= len(row) || dateCol >= len(row) {
+			continue
+		}
+		dates[strings.TrimSpace(row[objectCol])] = normalizeIndexDate(strings.TrimSpace(row[dateCol]))
+	}
+}
+
+// normalizeIndexDate writes SUB_DATE as YYYY-MM-DD so dates compare as strings
+func normalizeIndexDate(value string) string {
+	for _, layout := range indexDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return value
+}
+
+// submitted is the submission date, or the ReturnTs date for filings missing
+// from the index files
+func (v filingVersion) submitted() string {
+	if v.submissionDate != "" || len(v.returnTs) < len("2006-01-02") {
+		return v.submissionDate
+	}
+	return v.returnTs[:len("2006-01-02")]
+}
+
+// supersedes reports whether version a ranks before version b
+func (a filingVersion) supersedes(b filingVersion) bool {
+	if a.amended != b.amended {
+		return a.amended
+	}
+	if a.submitted() != b.submitted() {
+		return a.submitted() > b.submitted()
+	}
+	if a.returnTs != b.returnTs {
+		return a.returnTs > b.returnTs
+	}
+	return a.objectID > b.objectID
+}
+
+// resolveFilingVersions writes the filing_versions table and, with -latest,
+// removes superseded filings from every output of the run. It returns the
+// file names of the superseded filings.
+func resolveFilingVersions(outputPath, baseDir string, versions []filingVersion, options csvOptions) (map[string]bool, error) {
+	dates, err := loadSubmissionDates(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		versions[i].submissionDate = dates[versions[i].objectID]
+	}
+
+	// Filings without an EIN or tax period cannot be matched and stand alone
+	groups := make(map[[2]string][]filingVersion)
+	for _, v := range versions {
+		key := [2]string{v.ein, v.taxPeriodEnd}
+		if v.ein == "" || v.taxPeriodEnd == "" {
+			key = [2]string{"", v.fileName}
+		}
+		groups[key] = append(groups[key], v)
+	}
+	keys := make([][2]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	table, err := newTableWriter(outputPath, &filingVersionsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer table.Close()
+
+	superseded := make(map[string]bool)
+	for _, key := range keys {
+		group := groups[key]
+		sort.Slice(group, func(i, j int) bool { return group[i].supersedes(group[j]) })
+
+		for rank, v := range group {
+			row := map[string]string{
+				"ObjectID":        v.objectID,
+				"TaxPeriodEnd":    v.taxPeriodEnd,
+				"AmendedReturn":   strconv.FormatBool(v.amended),
+				"ReturnTimestamp": v.returnTs,
+				"SubmissionDate":  v.submissionDate,
+				"Versions":        strconv.Itoa(len(group)),
+				"VersionRank":     strconv.Itoa(rank + 1),
+				"Status":          "authoritative",
+			}
+			if rank > 0 {
+				row["Status"] = "superseded"
+				row["SupersededBy"] = group[0].fileName
+				superseded[v.fileName] = true
+			}
+			rowKeys := []string{v.fileName, v.ein, v.taxYear}
+			if err := table.writeRows([]map[string]string{row}, nil, rowKeys); err != nil {
+				return nil, err
+			}
+		}
+	}
+	log.Printf("Resolved %d filings: %d superseded", len(versions), len(superseded))
+
+	if !options.latestOnly || len(superseded) == 0 {
+		return superseded, nil
+	}
+
+	outputs := []string{outputPath}
+	for _, spec := range tableSpecs {
+		outputs = append(outputs, tablePath(outputPath, spec))
+	}
+	if options.provenance {
+		outputs = append(outputs, tablePath(outputPath, &provenanceTable))
+	}
+	for _, path := range outputs {
+		if err := dropFilings(path, superseded); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("Removed %d superseded filings from the outputs", len(superseded))
+	return superseded, nil
+}
+
+// dropFilings rewrites a CSV output without the rows of the given file names,
+// which every output keeps in its first column
+func dropFilings(path string, fileNames map[string]bool) error {
+	return rewriteCSV(path, func(row []string, header bool) bool {
+		return header || len(row) == 0 || !fileNames[row[0]]
+	})
+}
+
+// rewriteCSV passes every row of a CSV file, header first, to edit, which may
+// change it in place, and replaces the file with the rows edit keeps
+func rewriteCSV(path string, edit func(row []string, header bool) bool) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer in.Close()
+
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmpPath, err)
+	}
+	defer out.Close()
+
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	writer := csv.NewWriter(out)
+
+	for first := true; ; first = false {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !edit(row, first) {
+			continue
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush %s: %w", tmpPath, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}